@@ -8,7 +8,6 @@ import (
 	"github.com/aws/aws-cdk-go/awscdk/v2/awsaps"
 	"github.com/aws/constructs-go/constructs/v10"
 	"github.com/aws/jsii-runtime-go"
-	"github.com/sirupsen/logrus"
 )
 
 type AmpStackProps struct {
@@ -17,12 +16,20 @@ type AmpStackProps struct {
 	WorkspaceName string            `json:"workspaceName"`
 }
 
-func (aps *AmpStackProps) setWorkspaceName() {
+func (aps *AmpStackProps) SetDefaults() {
 	if aps.WorkspaceName == "" {
 		aps.WorkspaceName = os.Getenv("ACORN_WORKSPACE")
 	}
 }
 
+func (aps *AmpStackProps) Validate() error {
+	return nil
+}
+
+func (aps *AmpStackProps) GetTags() map[string]string {
+	return aps.Tags
+}
+
 func NewAmpStack(scope constructs.Construct, id string, props *AmpStackProps) awscdk.Stack {
 	var sprops awscdk.StackProps
 	if props != nil {
@@ -45,21 +52,12 @@ func NewAmpStack(scope constructs.Construct, id string, props *AmpStackProps) aw
 }
 
 func main() {
-	defer jsii.Close()
-
-	app := common.NewAcornTaggedApp(nil)
 	stackProps := &AmpStackProps{
 		StackProps: *common.NewAWSCDKStackProps(),
 	}
 
-	if err := common.NewConfig(stackProps); err != nil {
-		logrus.Fatal(err)
-	}
-	stackProps.setWorkspaceName()
-
-	common.AppendScopedTags(app, stackProps.Tags)
-
-	NewAmpStack(app, "ampStack", stackProps)
-
-	app.Synth(nil)
+	common.RunStack(stackProps, func(scope constructs.Construct, props *AmpStackProps) error {
+		NewAmpStack(scope, "ampStack", props)
+		return nil
+	})
 }
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/acorn-io/services/aws/libs/common"
+	"github.com/acorn-io/services/aws/libs/common/stacktest"
+)
+
+func TestNewAmpStackGolden(t *testing.T) {
+	tests := []struct {
+		name      string
+		overrides string
+	}{
+		{
+			name: "defaults",
+		},
+		{
+			name:      "named",
+			overrides: `{"workspaceName": "metrics", "tags": {"team": "platform"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ACORN_WORKSPACE", "my-app-workspace")
+			props := &AmpStackProps{StackProps: stacktest.StackProps()}
+			app := stacktest.NewAppWithConfig(t, stacktest.AcornfileConfig(t, "Acornfile", tt.overrides), props)
+			stacktest.AssertGolden(t, NewAmpStack(app, "ampStack", props))
+		})
+	}
+}
+
+func TestAcornfileArgs(t *testing.T) {
+	if err := common.ValidateAcornfileArgs(&AmpStackProps{}, "Acornfile"); err != nil {
+		t.Error(err)
+	}
+}
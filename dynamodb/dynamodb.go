@@ -1,12 +1,14 @@
 package main
 
 import (
+	"errors"
+	"fmt"
+
 	"github.com/acorn-io/services/aws/libs/common"
 	"github.com/aws/aws-cdk-go/awscdk/v2"
 	"github.com/aws/aws-cdk-go/awscdk/v2/awsdynamodb"
 	"github.com/aws/constructs-go/constructs/v10"
 	"github.com/aws/jsii-runtime-go"
-	"github.com/sirupsen/logrus"
 )
 
 type DynamoStackProps struct {
@@ -18,20 +20,36 @@ type DynamoStackProps struct {
 	SortKeyType          string            `json:"sortKeyType" yaml:"sortKeyType"`
 	UserTags             map[string]string `json:"tags" yaml:"tags"`
 	SkipSnapshotOnDelete bool              `json:"skipSnapshotOnDelete" yaml:"skipSnapshotOnDelete"`
+	// Resource types the user tags are applied to
+	common.TagPropagationProps
+	// Read by the cdk-runner from the CDK_RUNNER_DELETE_PROTECTION env var
+	DeletionProtection bool `json:"deletionProtection" yaml:"deletionProtection"`
+}
+
+var attributeTypes = map[string]awsdynamodb.AttributeType{
+	"STRING": awsdynamodb.AttributeType_STRING,
+	"BINARY": awsdynamodb.AttributeType_BINARY,
+	"NUMBER": awsdynamodb.AttributeType_NUMBER,
 }
 
-func mustGetAttributeType(attrType, arg string) awsdynamodb.AttributeType {
-	switch attrType {
-	case "STRING":
-		return awsdynamodb.AttributeType_STRING
-	case "BINARY":
-		return awsdynamodb.AttributeType_BINARY
-	case "NUMBER":
-		return awsdynamodb.AttributeType_NUMBER
+func (props *DynamoStackProps) SetDefaults() {}
+
+func (props *DynamoStackProps) Validate() error {
+	var errs []error
+	if _, ok := attributeTypes[props.PartitionKeyType]; !ok {
+		errs = append(errs, fmt.Errorf("partitionKeyType: unmatched attribute type: %s. Valid values are STRING, BINARY, and NUMBER.", props.PartitionKeyType))
+	}
+	if _, ok := attributeTypes[props.SortKeyType]; len(props.SortKey) > 0 && len(props.SortKeyType) > 0 && !ok {
+		errs = append(errs, fmt.Errorf("sortKeyType: unmatched attribute type: %s. Valid values are STRING, BINARY, and NUMBER.", props.SortKeyType))
+	}
+	if err := props.TagPropagationProps.Validate(); err != nil {
+		errs = append(errs, err)
 	}
+	return errors.Join(errs...)
+}
 
-	logrus.WithField("arg", arg).Fatalf("unmatched attribute type: %s. Valid values are STRING, BINARY, and NUMBER.", attrType)
-	return "" // this won't be hit given that the fatal log causes a panic
+func (props *DynamoStackProps) GetTags() map[string]string {
+	return props.UserTags
 }
 
 func NewDynamoStack(scope constructs.Construct, id string, props *DynamoStackProps) awscdk.Stack {
@@ -45,7 +63,7 @@ func NewDynamoStack(scope constructs.Construct, id string, props *DynamoStackPro
 	tableProps := &awsdynamodb.TablePropsV2{
 		PartitionKey: &awsdynamodb.Attribute{
 			Name: jsii.String(props.PartitionKey),
-			Type: mustGetAttributeType(props.PartitionKeyType, "partitionKeyType"),
+			Type: attributeTypes[props.PartitionKeyType],
 		},
 	}
 
@@ -62,7 +80,7 @@ func NewDynamoStack(scope constructs.Construct, id string, props *DynamoStackPro
 	if len(props.SortKey) > 0 && len(props.SortKeyType) > 0 {
 		tableProps.SortKey = &awsdynamodb.Attribute{
 			Name: jsii.String(props.SortKey),
-			Type: mustGetAttributeType(props.SortKeyType, "sortKeyType"),
+			Type: attributeTypes[props.SortKeyType],
 		}
 	}
 
@@ -79,20 +97,12 @@ func NewDynamoStack(scope constructs.Construct, id string, props *DynamoStackPro
 }
 
 func main() {
-	defer jsii.Close()
-
-	app := common.NewAcornTaggedApp(nil)
-
 	stackProps := &DynamoStackProps{
 		StackProps: *common.NewAWSCDKStackProps(),
 	}
 
-	err := common.NewConfig(stackProps)
-	if err != nil {
-		logrus.Fatal(err)
-	}
-
-	common.AppendScopedTags(app, stackProps.UserTags)
-	NewDynamoStack(app, "dynamoDbStack", stackProps)
-	app.Synth(nil)
+	common.RunStack(stackProps, func(scope constructs.Construct, props *DynamoStackProps) error {
+		NewDynamoStack(scope, "dynamoDbStack", props)
+		return nil
+	})
 }
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/acorn-io/services/aws/libs/common"
+	"github.com/acorn-io/services/aws/libs/common/stacktest"
+)
+
+func TestNewDynamoStackGolden(t *testing.T) {
+	tests := []struct {
+		name      string
+		overrides string
+	}{
+		{
+			name: "defaults",
+		},
+		{
+			name:      "sort key",
+			overrides: `{"tableName": "orders", "partitionKey": "customer", "partitionKeyType": "STRING", "sortKey": "created", "sortKeyType": "NUMBER", "tags": {"team": "platform"}, "skipSnapshotOnDelete": true}`,
+		},
+		{
+			name:      "tags excluded",
+			overrides: `{"tags": {"team": "platform"}, "tagsExcludeResourceTypes": ["AWS::DynamoDB::GlobalTable"]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			props := &DynamoStackProps{StackProps: stacktest.StackProps()}
+			app := stacktest.NewAppWithConfig(t, stacktest.AcornfileConfig(t, "Acornfile", tt.overrides), props)
+			stacktest.AssertGolden(t, NewDynamoStack(app, "dynamoDbStack", props))
+		})
+	}
+}
+
+func TestAcornfileArgs(t *testing.T) {
+	if err := common.ValidateAcornfileArgs(&DynamoStackProps{}, "Acornfile"); err != nil {
+		t.Error(err)
+	}
+}
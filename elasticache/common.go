@@ -3,8 +3,8 @@ package elasticache
 import (
 	"crypto/md5"
 	"encoding/hex"
-	"os"
 
+	"github.com/acorn-io/services/aws/libs/common/naming"
 	"github.com/aws/aws-cdk-go/awscdk/v2/awsec2"
 	"github.com/aws/aws-cdk-go/awscdk/v2/awselasticache"
 	"github.com/aws/constructs-go/constructs/v10"
@@ -13,18 +13,8 @@ import (
 
 // ResourceID returns an ID that can be used to uniquely identify resources built with the given prefix
 func ResourceID(clusterName string, prefix string) *string {
-	externalIdHash := md5.Sum([]byte(os.Getenv("ACORN_EXTERNAL_ID")))
-	clusterName = clusterName + "-" + hex.EncodeToString(externalIdHash[:])
-
-	if prefix != "" {
-		clusterName = prefix + "-" + clusterName
-	}
-
-	if len(clusterName) > 40 {
-		clusterName = clusterName[:40]
-	}
-
-	return jsii.String(clusterName)
+	externalIdHash := md5.Sum([]byte(naming.AcornFromEnv().ExternalID))
+	return jsii.String(naming.ElastiCache.Name(prefix, clusterName, hex.EncodeToString(externalIdHash[:])))
 }
 
 // GetPrivateSubnetGroup returns a new subnet group for the given elasticache stack
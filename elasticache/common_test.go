@@ -20,4 +20,12 @@ func TestResourceID(t *testing.T) {
 	if *id != *idAgain {
 		t.Error("expected matching IDs")
 	}
+
+	// The names of existing clusters must not change
+	if *id != "Sng-Redis-b404743aa606f19f658ee3a25bc879" {
+		t.Errorf("unexpected ID %s", *id)
+	}
+	if id := ResourceID("Redis", ""); *id != "Redis-b404743aa606f19f658ee3a25bc87994" {
+		t.Errorf("unexpected ID %s", *id)
+	}
 }
@@ -12,7 +12,6 @@ import (
 	"github.com/aws/aws-cdk-go/awscdk/v2/awselasticache"
 	"github.com/aws/constructs-go/constructs/v10"
 	"github.com/aws/jsii-runtime-go"
-	"github.com/sirupsen/logrus"
 )
 
 type memcachedStackProps struct {
@@ -22,6 +21,22 @@ type memcachedStackProps struct {
 	NodeType          string            `json:"nodeType" yaml:"nodeType"`
 	NumNodes          int               `json:"numNodes" yaml:"numNodes"`
 	TransitEncryption bool              `json:"transitEncryption" yaml:"transitEncryption"`
+	// Read by the cdk-runner from the CDK_RUNNER_DELETE_PROTECTION env var
+	DeletionProtection bool `json:"deletionProtection" yaml:"deletionProtection"`
+	common.IngressProps
+}
+
+// SetDefaults has nothing to default, the Acornfile sets the defaults of all args
+func (props *memcachedStackProps) SetDefaults() {}
+
+// Validate validates the ingress sources
+func (props *memcachedStackProps) Validate() error {
+	return props.IngressProps.Validate()
+}
+
+// GetTags returns the user tags applied to all resources
+func (props *memcachedStackProps) GetTags() map[string]string {
+	return props.UserTags
 }
 
 // NewMemcachedStack creates the new Memcached stack
@@ -43,7 +58,7 @@ func NewMemcachedStack(scope constructs.Construct, id string, props *memcachedSt
 	subnetGroup := elasticache.GetPrivateSubnetGroup(stack, elasticache.ResourceID(props.ClusterName, "Sng"), vpc)
 
 	// get the security group
-	sg := common.GetAllowAllVPCSecurityGroup(stack, elasticache.ResourceID(props.ClusterName, "Scg"), jsii.String("Acorn generated Elasticache security group"), vpc, 11211)
+	sg := common.GetIngressSecurityGroup(stack, elasticache.ResourceID(props.ClusterName, "Scg"), jsii.String("Acorn generated Elasticache security group"), vpc, 11211, &props.IngressProps)
 
 	vpcSecurityGroupIDs := make([]*string, 0)
 	vpcSecurityGroupIDs = append(vpcSecurityGroupIDs, sg.SecurityGroupId())
@@ -88,24 +103,12 @@ func NewMemcachedStack(scope constructs.Construct, id string, props *memcachedSt
 }
 
 func main() {
-	defer jsii.Close()
-
-	app := common.NewAcornTaggedApp(nil)
-
 	stackProps := &memcachedStackProps{
 		StackProps: *common.NewAWSCDKStackProps(),
 	}
 
-	err := common.NewConfig(stackProps)
-	if err != nil {
-		logrus.WithError(err).Fatal("failed to create config")
-	}
-
-	common.AppendScopedTags(app, stackProps.UserTags)
-	_, err = NewMemcachedStack(app, "MemcachedStack", stackProps)
-	if err != nil {
-		logrus.WithError(err).Fatal("failed to create Memcached stack")
-	}
-
-	app.Synth(nil)
+	common.RunStack(stackProps, func(scope constructs.Construct, props *memcachedStackProps) error {
+		_, err := NewMemcachedStack(scope, "MemcachedStack", props)
+		return err
+	})
 }
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/acorn-io/services/aws/libs/common"
+	"github.com/acorn-io/services/aws/libs/common/stacktest"
+)
+
+func TestNewMemcachedStackGolden(t *testing.T) {
+	tests := []struct {
+		name      string
+		overrides string
+	}{
+		{
+			name: "defaults",
+		},
+		{
+			name:      "transit encryption",
+			overrides: `{"clusterName": "Sessions", "tags": {"team": "platform"}, "allowedCidrs": ["10.1.0.0/16"], "allowedSecurityGroupIds": ["sg-0123456789abcdef0"], "nodeType": "cache.r7g.large", "numNodes": 3, "transitEncryption": true}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			props := &memcachedStackProps{StackProps: stacktest.StackProps()}
+			app := stacktest.NewAppWithConfig(t, stacktest.AcornfileConfig(t, "Acornfile", tt.overrides), props)
+			stack, err := NewMemcachedStack(app, "MemcachedStack", props)
+			if err != nil {
+				t.Fatal(err)
+			}
+			stacktest.AssertGolden(t, stack)
+		})
+	}
+}
+
+func TestAcornfileArgs(t *testing.T) {
+	if err := common.ValidateAcornfileArgs(&memcachedStackProps{}, "Acornfile"); err != nil {
+		t.Error(err)
+	}
+}
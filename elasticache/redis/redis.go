@@ -12,7 +12,6 @@ import (
 	"github.com/aws/aws-cdk-go/awscdk/v2/awssecretsmanager"
 	"github.com/aws/constructs-go/constructs/v10"
 	"github.com/aws/jsii-runtime-go"
-	"github.com/sirupsen/logrus"
 )
 
 type redisStackProps struct {
@@ -22,6 +21,22 @@ type redisStackProps struct {
 	NodeType             string            `json:"nodeType" yaml:"nodeType"`
 	NumNodes             int               `json:"numNodes" yaml:"numNodes"`
 	SkipSnapshotOnDelete bool              `json:"skipSnapshotOnDelete" yaml:"skipSnapshotOnDelete"`
+	// Read by the cdk-runner from the CDK_RUNNER_DELETE_PROTECTION env var
+	DeletionProtection bool `json:"deletionProtection" yaml:"deletionProtection"`
+	common.IngressProps
+}
+
+// SetDefaults has nothing to default, the Acornfile sets the defaults of all args
+func (props *redisStackProps) SetDefaults() {}
+
+// Validate validates the ingress sources
+func (props *redisStackProps) Validate() error {
+	return props.IngressProps.Validate()
+}
+
+// GetTags returns the user tags applied to all resources
+func (props *redisStackProps) GetTags() map[string]string {
+	return props.UserTags
 }
 
 // NewRedisStack creates the new Redis stack
@@ -43,7 +58,7 @@ func NewRedisStack(scope constructs.Construct, id string, props *redisStackProps
 	subnetGroup := elasticache.GetPrivateSubnetGroup(stack, elasticache.ResourceID(props.ClusterName, "Sng"), vpc)
 
 	// get the security group
-	sg := common.GetAllowAllVPCSecurityGroup(stack, elasticache.ResourceID(props.ClusterName, "Scg"), jsii.String("Acorn generated Elasticache security group"), vpc, 6379)
+	sg := common.GetIngressSecurityGroup(stack, elasticache.ResourceID(props.ClusterName, "Scg"), jsii.String("Acorn generated Elasticache security group"), vpc, 6379, &props.IngressProps)
 
 	vpcSecurityGroupIDs := make([]*string, 0)
 	vpcSecurityGroupIDs = append(vpcSecurityGroupIDs, sg.SecurityGroupId())
@@ -116,24 +131,12 @@ func NewRedisStack(scope constructs.Construct, id string, props *redisStackProps
 }
 
 func main() {
-	defer jsii.Close()
-
-	app := common.NewAcornTaggedApp(nil)
-
 	stackProps := &redisStackProps{
 		StackProps: *common.NewAWSCDKStackProps(),
 	}
 
-	err := common.NewConfig(stackProps)
-	if err != nil {
-		logrus.WithError(err).Fatal("failed to create config")
-	}
-
-	common.AppendScopedTags(app, stackProps.UserTags)
-	_, err = NewRedisStack(app, "RedisStack", stackProps)
-	if err != nil {
-		logrus.WithError(err).Fatal("failed to create Redis stack")
-	}
-
-	app.Synth(nil)
+	common.RunStack(stackProps, func(scope constructs.Construct, props *redisStackProps) error {
+		_, err := NewRedisStack(scope, "RedisStack", props)
+		return err
+	})
 }
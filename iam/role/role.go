@@ -3,16 +3,15 @@ package main
 import (
 	"errors"
 	"fmt"
-	"os"
 	"strings"
 
 	"github.com/acorn-io/services/aws/libs/common"
+	"github.com/acorn-io/services/aws/libs/common/naming"
 	"github.com/aws/aws-cdk-go/awscdk/v2"
 	"github.com/aws/aws-cdk-go/awscdk/v2/awsiam"
 	"github.com/aws/aws-sdk-go-v2/aws/arn"
 	"github.com/aws/constructs-go/constructs/v10"
 	"github.com/aws/jsii-runtime-go"
-	"github.com/sirupsen/logrus"
 )
 
 type IAMRoleStackProps struct {
@@ -21,15 +20,15 @@ type IAMRoleStackProps struct {
 	RoleName                  string                 `json:"roleName"`
 	TrustedArn                string                 `json:"trustedArn"`
 	Policy                    map[string]interface{} `json:"policy"`
-	MaxSessionDurationMinutes int                    `json:"maxSessionDurationMinutes"`
-	Path                      string                 `json:"path"`
+	MaxSessionDurationMinutes int                    `json:"maxSessionDurationMinutes" jsonschema:"minimum=60"`
+	Path                      string                 `json:"path" jsonschema:"pattern=^/"`
 	ExternalIds               string                 `json:"externalIds"`
 	Description               string                 `json:"description"`
 }
 
-func (rsp *IAMRoleStackProps) setDefaults() {
+func (rsp *IAMRoleStackProps) SetDefaults() {
 	if rsp.RoleName == "" {
-		rsp.RoleName = os.Getenv("ACORN_EXTERNAL_ID")
+		rsp.RoleName = naming.AcornFromEnv().ExternalIDName(naming.IAMRole)
 	}
 	if rsp.MaxSessionDurationMinutes == 0 {
 		rsp.MaxSessionDurationMinutes = 60
@@ -39,7 +38,7 @@ func (rsp *IAMRoleStackProps) setDefaults() {
 	}
 }
 
-func (rsp *IAMRoleStackProps) validateProps() error {
+func (rsp *IAMRoleStackProps) Validate() error {
 	var errs []error
 	if rsp.MaxSessionDurationMinutes < 60 {
 		errs = append(errs, fmt.Errorf("maxSessionDurationMinutes must be at least 60"))
@@ -56,6 +55,10 @@ func (rsp *IAMRoleStackProps) validateProps() error {
 	return errors.Join(errs...)
 }
 
+func (rsp *IAMRoleStackProps) GetTags() map[string]string {
+	return rsp.Tags
+}
+
 func newIAMRoleStack(scope constructs.Construct, id string, props *IAMRoleStackProps) (awscdk.Stack, error) {
 	var sprops awscdk.StackProps
 	if props != nil {
@@ -90,26 +93,12 @@ func newIAMRoleStack(scope constructs.Construct, id string, props *IAMRoleStackP
 }
 
 func main() {
-	defer jsii.Close()
-
-	app := common.NewAcornTaggedApp(nil)
 	stackProps := &IAMRoleStackProps{
 		StackProps: *common.NewAWSCDKStackProps(),
 	}
 
-	if err := common.NewConfig(stackProps); err != nil {
-		logrus.Fatal(err)
-	}
-	stackProps.setDefaults()
-	if err := stackProps.validateProps(); err != nil {
-		logrus.Fatalf("invalid stack properties: %v", err)
-	}
-
-	common.AppendScopedTags(app, stackProps.Tags)
-
-	if _, err := newIAMRoleStack(app, "iamRoleStack", stackProps); err != nil {
-		logrus.Fatal(err)
-	}
-
-	app.Synth(nil)
+	common.RunStack(stackProps, func(scope constructs.Construct, props *IAMRoleStackProps) error {
+		_, err := newIAMRoleStack(scope, "iamRoleStack", props)
+		return err
+	})
 }
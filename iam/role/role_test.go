@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/acorn-io/services/aws/libs/common"
+	"github.com/acorn-io/services/aws/libs/common/stacktest"
+)
+
+func TestNewIAMRoleStackGolden(t *testing.T) {
+	tests := []struct {
+		name      string
+		overrides string
+	}{
+		{
+			// trustedArn and policy are required
+			name:      "defaults",
+			overrides: `{"trustedArn": "arn:aws:iam::123456789012:root", "policy": {"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Action": "s3:GetObject", "Resource": "*"}]}}`,
+		},
+		{
+			name:      "external ids",
+			overrides: `{"roleName": "reader", "trustedArn": "arn:aws:iam::123456789012:role/ci", "tags": {"team": "platform"}, "policy": {"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Action": "s3:GetObject", "Resource": "*"}]}, "maxSessionDurationMinutes": 120, "path": "/acorn/", "externalIds": "one,two"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			props := &IAMRoleStackProps{StackProps: stacktest.StackProps()}
+			app := stacktest.NewAppWithConfig(t, stacktest.AcornfileConfig(t, "Acornfile", tt.overrides), props)
+			stack, err := newIAMRoleStack(app, "iamRoleStack", props)
+			if err != nil {
+				t.Fatal(err)
+			}
+			stacktest.AssertGolden(t, stack)
+		})
+	}
+}
+
+func TestAcornfileArgs(t *testing.T) {
+	if err := common.ValidateAcornfileArgs(&IAMRoleStackProps{}, "Acornfile"); err != nil {
+		t.Error(err)
+	}
+}
@@ -1,17 +1,14 @@
 package main
 
 import (
-	"os"
-	"strings"
-
 	"github.com/acorn-io/aws/kms/key/props"
 	"github.com/acorn-io/services/aws/libs/common"
+	"github.com/acorn-io/services/aws/libs/common/naming"
 	"github.com/aws/aws-cdk-go/awscdk/v2"
 	"github.com/aws/aws-cdk-go/awscdk/v2/awsiam"
 	"github.com/aws/aws-cdk-go/awscdk/v2/awskms"
 	"github.com/aws/constructs-go/constructs/v10"
 	"github.com/aws/jsii-runtime-go"
-	"github.com/sirupsen/logrus"
 )
 
 func NewKMSKeyStack(scope constructs.Construct, id string, props *props.KMSKeyStackProps) (awscdk.Stack, error) {
@@ -41,7 +38,7 @@ func NewKMSKeyStack(scope constructs.Construct, id string, props *props.KMSKeySt
 	if len(props.KeyAlias) > 0 {
 		keyProps.Alias = jsii.String(props.KeyAlias)
 	} else {
-		keyProps.Alias = jsii.String(strings.ReplaceAll(os.Getenv("DEFAULT_KEY_ALIAS"), ".", "-"))
+		keyProps.Alias = jsii.String(naming.AcornFromEnv().ResourceName(naming.KMSAlias))
 	}
 	if len(props.Description) > 0 {
 		keyProps.Description = jsii.String(props.Description)
@@ -63,26 +60,12 @@ func NewKMSKeyStack(scope constructs.Construct, id string, props *props.KMSKeySt
 }
 
 func main() {
-	defer jsii.Close()
-
-	app := common.NewAcornTaggedApp(nil)
 	stackProps := &props.KMSKeyStackProps{
 		StackProps: *common.NewAWSCDKStackProps(),
 	}
 
-	if err := common.NewConfig(stackProps); err != nil {
-		logrus.Fatal(err)
-	}
-	stackProps.SetDefaults()
-	if err := stackProps.ValidateProps(); err != nil {
-		logrus.Fatalf("invalid stack properties: %s", err)
-	}
-
-	common.AppendScopedTags(app, stackProps.Tags)
-
-	if _, err := NewKMSKeyStack(app, "kmsKeyStack", stackProps); err != nil {
-		logrus.Fatal(err)
-	}
-
-	app.Synth(nil)
+	common.RunStack(stackProps, func(scope constructs.Construct, stackProps *props.KMSKeyStackProps) error {
+		_, err := NewKMSKeyStack(scope, "kmsKeyStack", stackProps)
+		return err
+	})
 }
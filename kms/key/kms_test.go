@@ -0,0 +1,35 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/acorn-io/aws/kms/key/props"
+	"github.com/acorn-io/services/aws/libs/common/stacktest"
+)
+
+func TestNewKMSKeyStackGolden(t *testing.T) {
+	tests := []struct {
+		name      string
+		overrides string
+	}{
+		{
+			name: "defaults",
+		},
+		{
+			name:      "signing key",
+			overrides: `{"keyName": "signer", "adminArn": "arn:aws:iam::123456789012:role/admin", "tags": {"team": "platform"}, "keyAlias": "signer", "enabled": true, "keySpec": "ECC_NIST_P256", "keyUsage": "SIGN_VERIFY", "pendingWindowDays": 30}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stackProps := &props.KMSKeyStackProps{StackProps: stacktest.StackProps()}
+			app := stacktest.NewAppWithConfig(t, stacktest.AcornfileConfig(t, "Acornfile", tt.overrides), stackProps)
+			stack, err := NewKMSKeyStack(app, "kmsKeyStack", stackProps)
+			if err != nil {
+				t.Fatal(err)
+			}
+			stacktest.AssertGolden(t, stack)
+		})
+	}
+}
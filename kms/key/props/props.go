@@ -22,9 +22,9 @@ type KMSKeyStackProps struct {
 	Description       string                 `json:"description"`
 	Enabled           bool                   `json:"enabled"`
 	EnableKeyRotation bool                   `json:"enableKeyRotation"`
-	KeySpec           string                 `json:"keySpec"`
-	KeyUsage          string                 `json:"keyUsage"`
-	PendingWindowDays int                    `json:"pendingWindowDays"`
+	KeySpec           string                 `json:"keySpec" jsonschema:"enum=SYMMETRIC_DEFAULT|RSA_2048|RSA_3072|RSA_4096|ECC_NIST_P256|ECC_NIST_P384|ECC_NIST_P521|ECC_SECG_P256K1|HMAC_224|HMAC_256|HMAC_384|HMAC_512"`
+	KeyUsage          string                 `json:"keyUsage" jsonschema:"enum=ENCRYPT_DECRYPT|SIGN_VERIFY|GENERATE_VERIFY_MAC"`
+	PendingWindowDays int                    `json:"pendingWindowDays" jsonschema:"minimum=7,maximum=30"`
 	KeyPolicy         map[string]interface{} `json:"keyPolicy"`
 }
 
@@ -62,7 +62,7 @@ func (ksp *KMSKeyStackProps) SetDefaults() {
 	}
 }
 
-func (ksp *KMSKeyStackProps) ValidateProps() error {
+func (ksp *KMSKeyStackProps) Validate() error {
 	var errs []error
 	if len(ksp.AdminArn) > 0 {
 		if _, err := arn.Parse(ksp.AdminArn); err != nil {
@@ -78,6 +78,10 @@ func (ksp *KMSKeyStackProps) ValidateProps() error {
 	return errors.Join(errs...)
 }
 
+func (ksp *KMSKeyStackProps) GetTags() map[string]string {
+	return ksp.Tags
+}
+
 func (ksp *KMSKeyStackProps) GetKeySpecAndUsage() (awskms.KeySpec, awskms.KeyUsage, error) {
 	var kmsUsage awskms.KeyUsage
 	switch ksp.KeyUsage {
@@ -3,6 +3,8 @@ package props
 import (
 	"strings"
 	"testing"
+
+	"github.com/acorn-io/services/aws/libs/common"
 )
 
 func TestPropsValidation(t *testing.T) {
@@ -74,7 +76,7 @@ func TestPropsValidation(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if err := tt.props.ValidateProps(); err != nil {
+			if err := tt.props.Validate(); err != nil {
 				if tt.errContains == "" {
 					t.Errorf("unexpected error: %s", err)
 				} else if !strings.Contains(err.Error(), tt.errContains) {
@@ -86,3 +88,9 @@ func TestPropsValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestAcornfileArgs(t *testing.T) {
+	if err := common.ValidateAcornfileArgs(&KMSKeyStackProps{}, "../Acornfile"); err != nil {
+		t.Error(err)
+	}
+}
@@ -1,8 +1,12 @@
 package common
 
 import (
-	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
 	"os"
+	"regexp"
+	"strings"
 
 	"github.com/aws/aws-cdk-go/awscdk/v2"
 	"github.com/aws/aws-cdk-go/awscdk/v2/awsec2"
@@ -11,10 +15,16 @@ import (
 )
 
 const (
-	configFile = "/app/config.json"
+	// AssetsBucketEnvKey is the bucket the file assets of the stacks, like Lambda code, are published to
+	AssetsBucketEnvKey = "CDK_ASSETS_BUCKET"
+	// AssetsRepositoryEnvKey is the ECR repository the image assets of the stacks are published to
+	AssetsRepositoryEnvKey = "CDK_ASSETS_REPOSITORY"
 )
 
 var (
+	securityGroupIDRegex = regexp.MustCompile(`^sg-[0-9a-f]{8}([0-9a-f]{9})?$`)
+	prefixListIDRegex    = regexp.MustCompile(`^pl-[0-9a-f]{8}([0-9a-f]{9})?$`)
+
 	acornTags = map[string]string{
 		"acorn.io/managed":      "true",
 		"acorn.io/project-name": os.Getenv("ACORN_PROJECT"),
@@ -23,16 +33,13 @@ var (
 	}
 )
 
-func ConfigBytes() ([]byte, error) {
-	return os.ReadFile(configFile)
-}
-
+// NewConfig strictly decodes the config file into props, see DecodeConfig
 func NewConfig(props any) error {
 	conf, err := ConfigBytes()
 	if err != nil {
 		return err
 	}
-	return json.Unmarshal(conf, props)
+	return DecodeConfig(conf, props)
 }
 
 func NewAcornTaggedApp(props *awscdk.AppProps) awscdk.App {
@@ -42,10 +49,7 @@ func NewAcornTaggedApp(props *awscdk.AppProps) awscdk.App {
 }
 
 func AppendScopedTags(scope constructs.Construct, tags map[string]string) {
-	scopedTags := awscdk.Tags_Of(scope)
-	for k, v := range tags {
-		scopedTags.Add(jsii.String(k), jsii.String(v), &awscdk.TagProps{})
-	}
+	AppendScopedTagsWithProps(scope, tags, nil)
 }
 
 func GetVpcID() string {
@@ -54,9 +58,7 @@ func GetVpcID() string {
 
 func NewAWSCDKStackProps() *awscdk.StackProps {
 	return &awscdk.StackProps{
-		Synthesizer: awscdk.NewDefaultStackSynthesizer(&awscdk.DefaultStackSynthesizerProps{
-			GenerateBootstrapVersionRule: jsii.Bool(false),
-		}),
+		Synthesizer: NewSynthesizer(),
 		Env: &awscdk.Environment{
 			Account: jsii.String(os.Getenv("CDK_DEFAULT_ACCOUNT")),
 			Region:  jsii.String(os.Getenv("CDK_DEFAULT_REGION")),
@@ -64,20 +66,88 @@ func NewAWSCDKStackProps() *awscdk.StackProps {
 	}
 }
 
-// GetAllowAllVPCSecurityGroup returns a security group that allows traffic to and from the vpc over the given port
-func GetAllowAllVPCSecurityGroup(scope constructs.Construct, name *string, description *string, vpc awsec2.IVpc, port int) awsec2.SecurityGroup {
+// NewSynthesizer returns the synthesizer of the stacks. When an assets bucket or repository is set, the assets are
+// published there by the cdk-runner with its own credentials, instead of to the resources of the CDK bootstrap.
+func NewSynthesizer() awscdk.IStackSynthesizer {
+	bucket := os.Getenv(AssetsBucketEnvKey)
+	repository := os.Getenv(AssetsRepositoryEnvKey)
+	if bucket == "" && repository == "" {
+		return awscdk.NewDefaultStackSynthesizer(&awscdk.DefaultStackSynthesizerProps{
+			GenerateBootstrapVersionRule: jsii.Bool(false),
+		})
+	}
+
+	props := &awscdk.CliCredentialsStackSynthesizerProps{}
+	if bucket != "" {
+		props.FileAssetsBucketName = jsii.String(bucket)
+	}
+	if repository != "" {
+		props.ImageAssetsRepositoryName = jsii.String(repository)
+	}
+	return awscdk.NewCliCredentialsStackSynthesizer(props)
+}
+
+// IngressProps configures the sources allowed to reach the port of a security group created by
+// GetIngressSecurityGroup. When no sources are set, only the private subnets of the VPC are allowed.
+type IngressProps struct {
+	AllowedCIDRs            []string `json:"allowedCidrs" yaml:"allowedCidrs"`
+	AllowedSecurityGroupIDs []string `json:"allowedSecurityGroupIds" yaml:"allowedSecurityGroupIds" jsonschema:"pattern=^sg-[0-9a-f]{8}([0-9a-f]{9})?$"`
+	AllowedPrefixListIDs    []string `json:"allowedPrefixListIds" yaml:"allowedPrefixListIds" jsonschema:"pattern=^pl-[0-9a-f]{8}([0-9a-f]{9})?$"`
+}
+
+// Validate checks that the ingress sources are valid CIDRs, security group IDs and prefix list IDs
+func (ip *IngressProps) Validate() error {
+	var errs []error
+	for _, cidr := range ip.AllowedCIDRs {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			errs = append(errs, fmt.Errorf("allowedCidrs: %q is not a valid CIDR block", cidr))
+		}
+	}
+	for _, id := range ip.AllowedSecurityGroupIDs {
+		if !securityGroupIDRegex.MatchString(id) {
+			errs = append(errs, fmt.Errorf("allowedSecurityGroupIds: %q is not a valid security group ID", id))
+		}
+	}
+	for _, id := range ip.AllowedPrefixListIDs {
+		if !prefixListIDRegex.MatchString(id) {
+			errs = append(errs, fmt.Errorf("allowedPrefixListIds: %q is not a valid prefix list ID", id))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// GetIngressSecurityGroup returns a security group that allows traffic to the given port from the sources of the
+// ingress props, or from the private subnets of the vpc if none are set. Public subnets are never allowed implicitly.
+func GetIngressSecurityGroup(scope constructs.Construct, name *string, description *string, vpc awsec2.IVpc, port int, ingress *IngressProps) awsec2.SecurityGroup {
 	sg := awsec2.NewSecurityGroup(scope, name, &awsec2.SecurityGroupProps{
 		Vpc:              vpc,
 		AllowAllOutbound: jsii.Bool(true),
 		Description:      description,
 	})
 
-	for _, i := range *vpc.PrivateSubnets() {
-		sg.AddIngressRule(awsec2.Peer_Ipv4(i.Ipv4CidrBlock()), awsec2.Port_Tcp(jsii.Number(port)), jsii.String("Allow from private subnets"), jsii.Bool(false))
+	tcpPort := awsec2.Port_Tcp(jsii.Number(port))
+
+	if ingress == nil || (len(ingress.AllowedCIDRs) == 0 && len(ingress.AllowedSecurityGroupIDs) == 0 && len(ingress.AllowedPrefixListIDs) == 0) {
+		for _, i := range *vpc.PrivateSubnets() {
+			sg.AddIngressRule(awsec2.Peer_Ipv4(i.Ipv4CidrBlock()), tcpPort, jsii.String("Allow from private subnets"), jsii.Bool(false))
+		}
+		return sg
+	}
+
+	for _, cidr := range ingress.AllowedCIDRs {
+		peer := awsec2.Peer_Ipv4(jsii.String(cidr))
+		if strings.Contains(cidr, ":") {
+			peer = awsec2.Peer_Ipv6(jsii.String(cidr))
+		}
+		sg.AddIngressRule(peer, tcpPort, jsii.String("Allow from "+cidr), jsii.Bool(false))
+	}
+
+	for _, id := range ingress.AllowedSecurityGroupIDs {
+		sg.AddIngressRule(awsec2.Peer_SecurityGroupId(jsii.String(id), nil), tcpPort, jsii.String("Allow from "+id), jsii.Bool(false))
 	}
 
-	for _, i := range *vpc.PublicSubnets() {
-		sg.AddIngressRule(awsec2.Peer_Ipv4(i.Ipv4CidrBlock()), awsec2.Port_Tcp(jsii.Number(port)), jsii.String("Allow from public subnets"), jsii.Bool(false))
+	for _, id := range ingress.AllowedPrefixListIDs {
+		sg.AddIngressRule(awsec2.Peer_PrefixList(jsii.String(id)), tcpPort, jsii.String("Allow from "+id), jsii.Bool(false))
 	}
 
 	return sg
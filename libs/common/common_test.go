@@ -0,0 +1,100 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awss3assets"
+	"github.com/aws/jsii-runtime-go"
+)
+
+func TestIngressPropsValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		props       IngressProps
+		errContains string
+	}{
+		{
+			name:  "no sources",
+			props: IngressProps{},
+		},
+		{
+			name: "valid sources",
+			props: IngressProps{
+				AllowedCIDRs:            []string{"10.0.0.0/16", "2001:db8::/32"},
+				AllowedSecurityGroupIDs: []string{"sg-0123456789abcdef0", "sg-01234567"},
+				AllowedPrefixListIDs:    []string{"pl-63a5400a"},
+			},
+		},
+		{
+			name:        "invalid CIDR",
+			props:       IngressProps{AllowedCIDRs: []string{"10.0.0.0"}},
+			errContains: `allowedCidrs: "10.0.0.0" is not a valid CIDR block`,
+		},
+		{
+			name:        "invalid security group ID",
+			props:       IngressProps{AllowedSecurityGroupIDs: []string{"my-app"}},
+			errContains: `allowedSecurityGroupIds: "my-app" is not a valid security group ID`,
+		},
+		{
+			name:        "invalid prefix list ID",
+			props:       IngressProps{AllowedPrefixListIDs: []string{"sg-0123456789abcdef0"}},
+			errContains: `allowedPrefixListIds: "sg-0123456789abcdef0" is not a valid prefix list ID`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.props.Validate(); err != nil {
+				if tt.errContains == "" {
+					t.Errorf("unexpected error: %s", err)
+				} else if !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("expected error to contain %q, got %q", tt.errContains, err)
+				}
+			} else if tt.errContains != "" {
+				t.Errorf("expected error to contain %q, got nil", tt.errContains)
+			}
+		})
+	}
+}
+
+func TestNewSynthesizer(t *testing.T) {
+	tests := []struct {
+		name       string
+		bucket     string
+		bucketName string
+	}{
+		{
+			name:       "bootstrap bucket",
+			bucketName: "cdk-hnb659fds-assets-123456789012-us-east-1",
+		},
+		{
+			name:       "assets bucket",
+			bucket:     "my-assets",
+			bucketName: "my-assets",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(AssetsBucketEnvKey, tt.bucket)
+			t.Setenv(AssetsRepositoryEnvKey, "")
+
+			stack := awscdk.NewStack(awscdk.NewApp(nil), jsii.String("stack"), &awscdk.StackProps{
+				Synthesizer: NewSynthesizer(),
+				Env: &awscdk.Environment{
+					Account: jsii.String("123456789012"),
+					Region:  jsii.String("us-east-1"),
+				},
+			})
+			asset := awss3assets.NewAsset(stack, jsii.String("Asset"), &awss3assets.AssetProps{
+				Path: jsii.String("common.go"),
+			})
+			if got := fmt.Sprint(stack.Resolve(asset.S3BucketName())); got != tt.bucketName {
+				t.Errorf("expected the asset to be published to %s, got %s", tt.bucketName, got)
+			}
+		})
+	}
+}
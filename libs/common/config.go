@@ -0,0 +1,166 @@
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"sigs.k8s.io/yaml"
+)
+
+const defaultConfigFile = "/app/config.json"
+
+var (
+	// ConfigFile is the path of the config file read by ConfigBytes. It is set from the CONFIG_FILE env var and
+	// defaults to the file the Acornfiles render. RunStack overrides it with its --config flag. A path of - reads
+	// the config from stdin.
+	ConfigFile = configFileFromEnv()
+
+	stackPropsType = reflect.TypeOf(awscdk.StackProps{})
+)
+
+func configFileFromEnv() string {
+	if f := os.Getenv("CONFIG_FILE"); f != "" {
+		return f
+	}
+	return defaultConfigFile
+}
+
+// ConfigBytes reads the config file as JSON. Files with a .yaml or .yml extension are converted from YAML, and so
+// is config that is not valid JSON and does not have a .json extension, like config read from stdin.
+func ConfigBytes() ([]byte, error) {
+	var (
+		data []byte
+		err  error
+	)
+	if ConfigFile == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(ConfigFile)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return configJSON(ConfigFile, data)
+}
+
+func configJSON(path string, data []byte) ([]byte, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return data, nil
+	case ".yaml", ".yml":
+	default:
+		if json.Valid(data) {
+			return data, nil
+		}
+	}
+
+	data, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return data, nil
+}
+
+// DecodeConfig decodes the JSON config into props and reports every field of the config that props does not
+// declare, so misspelled args are not silently ignored. Only fields with a json tag are part of the config.
+func DecodeConfig(data []byte, props any) error {
+	if err := json.Unmarshal(data, props); err != nil {
+		return err
+	}
+	return errors.Join(unknownFields(data, reflect.TypeOf(props), "")...)
+}
+
+// configFields returns the fields of a struct type that are part of the config, by json name. Like encoding/json,
+// the fields of embedded structs without a json tag are promoted and the shallower field wins. The embedded
+// awscdk.StackProps is set in code, so it is not part of the config.
+func configFields(t reflect.Type) map[string]reflect.StructField {
+	fields := map[string]reflect.StructField{}
+	var embedded []reflect.Type
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, hasTag := f.Tag.Lookup("json")
+		name, _, _ := strings.Cut(tag, ",")
+
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct && ft != stackPropsType {
+				embedded = append(embedded, ft)
+			}
+			continue
+		}
+		if !f.IsExported() || !hasTag || name == "" || name == "-" {
+			continue
+		}
+		fields[name] = f
+	}
+
+	for _, et := range embedded {
+		for name, f := range configFields(et) {
+			if _, ok := fields[name]; !ok {
+				fields[name] = f
+			}
+		}
+	}
+	return fields
+}
+
+func unknownFields(data json.RawMessage, t reflect.Type, path string) []error {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	var errs []error
+	switch t.Kind() {
+	case reflect.Struct:
+		var values map[string]json.RawMessage
+		if err := json.Unmarshal(data, &values); err != nil {
+			return nil
+		}
+		fields := configFields(t)
+		for _, name := range sortedKeys(values) {
+			f, ok := fields[name]
+			if !ok {
+				errs = append(errs, fmt.Errorf("unknown field %q", path+name))
+				continue
+			}
+			errs = append(errs, unknownFields(values[name], f.Type, path+name+".")...)
+		}
+	case reflect.Slice, reflect.Array:
+		var values []json.RawMessage
+		if err := json.Unmarshal(data, &values); err != nil {
+			return nil
+		}
+		for i, v := range values {
+			errs = append(errs, unknownFields(v, t.Elem(), fmt.Sprintf("%s[%d].", strings.TrimSuffix(path, "."), i))...)
+		}
+	case reflect.Map:
+		var values map[string]json.RawMessage
+		if err := json.Unmarshal(data, &values); err != nil {
+			return nil
+		}
+		for _, k := range sortedKeys(values) {
+			errs = append(errs, unknownFields(values[k], t.Elem(), path+k+".")...)
+		}
+	}
+	return errs
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
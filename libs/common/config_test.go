@@ -0,0 +1,180 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+)
+
+type testPolicy struct {
+	Sid     string   `json:"sid"`
+	Actions []string `json:"actions"`
+}
+
+type testConfigProps struct {
+	awscdk.StackProps
+	QueueName         string                `json:"queueName"`
+	VisibilityTimeout int                   `json:"visibilityTimeout,omitempty"`
+	Policies          []testPolicy          `json:"policies"`
+	PolicyMap         map[string]testPolicy `json:"policyMap"`
+	Tags              map[string]string     `json:"tags"`
+	ExternalID        string
+	IngressProps
+}
+
+func TestDecodeConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		conf        string
+		errContains []string
+	}{
+		{
+			name: "known fields",
+			conf: `{"queueName": "q", "visibilityTimeout": 30, "policies": [{"sid": "a", "actions": ["sqs:*"]}], "tags": {"any": "key"}, "allowedCidrs": ["10.0.0.0/16"]}`,
+		},
+		{
+			name:        "misspelled field",
+			conf:        `{"queueName": "q", "visibiltyTimeout": 30}`,
+			errContains: []string{`unknown field "visibiltyTimeout"`},
+		},
+		{
+			name:        "every unknown field is reported",
+			conf:        `{"queue": "q", "policies": [{"sid": "a"}, {"action": "sqs:*"}], "policyMap": {"p": {"sids": "a"}}}`,
+			errContains: []string{`unknown field "queue"`, `unknown field "policies[1].action"`, `unknown field "policyMap.p.sids"`},
+		},
+		{
+			name:        "untagged and stack props fields are not config",
+			conf:        `{"ExternalID": "id", "env": {}}`,
+			errContains: []string{`unknown field "ExternalID"`, `unknown field "env"`},
+		},
+		{
+			name:        "type errors",
+			conf:        `{"visibilityTimeout": "30"}`,
+			errContains: []string{"cannot unmarshal string into Go struct field testConfigProps.visibilityTimeout of type int"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := DecodeConfig([]byte(tt.conf), &testConfigProps{})
+			if len(tt.errContains) == 0 {
+				if err != nil {
+					t.Errorf("unexpected error: %s", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected errors %q, got nil", tt.errContains)
+			}
+			for _, e := range tt.errContains {
+				if !strings.Contains(err.Error(), e) {
+					t.Errorf("expected error to contain %q, got %q", e, err)
+				}
+			}
+		})
+	}
+}
+
+func TestConfigBytes(t *testing.T) {
+	tests := []struct {
+		name        string
+		file        string
+		conf        string
+		stdin       bool
+		want        string
+		errContains string
+	}{
+		{
+			name: "json",
+			file: "config.json",
+			conf: `{"queueName": "q"}`,
+			want: `{"queueName": "q"}`,
+		},
+		{
+			name: "yaml",
+			file: "config.yaml",
+			conf: "queueName: q\nallowedCidrs:\n- 10.0.0.0/16\n",
+			want: `{"allowedCidrs":["10.0.0.0/16"],"queueName":"q"}`,
+		},
+		{
+			name: "yml",
+			file: "config.yml",
+			conf: "queueName: q\n",
+			want: `{"queueName":"q"}`,
+		},
+		{
+			name: "yaml without extension",
+			file: "config",
+			conf: "queueName: q\n",
+			want: `{"queueName":"q"}`,
+		},
+		{
+			name:  "stdin json",
+			file:  "stdin",
+			conf:  `{"queueName": "q"}`,
+			stdin: true,
+			want:  `{"queueName": "q"}`,
+		},
+		{
+			name:  "stdin yaml",
+			file:  "stdin",
+			conf:  "queueName: q\n",
+			stdin: true,
+			want:  `{"queueName":"q"}`,
+		},
+		{
+			name:        "invalid yaml",
+			file:        "config.yaml",
+			conf:        "queueName: [q\n",
+			errContains: "config.yaml: yaml: line 1",
+		},
+		{
+			name:        "missing file",
+			file:        "missing.json",
+			errContains: "no such file or directory",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, tt.file)
+			if tt.conf != "" {
+				if err := os.WriteFile(path, []byte(tt.conf), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			configFile, stdin := ConfigFile, os.Stdin
+			defer func() {
+				ConfigFile, os.Stdin = configFile, stdin
+			}()
+			ConfigFile = path
+			if tt.stdin {
+				f, err := os.Open(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer f.Close()
+				ConfigFile, os.Stdin = "-", f
+			}
+
+			got, err := ConfigBytes()
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Fatalf("expected error to contain %q, got %v", tt.errContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
@@ -6,6 +6,8 @@ require (
 	github.com/aws/aws-cdk-go/awscdk/v2 v2.87.0
 	github.com/aws/constructs-go/constructs/v10 v10.2.69
 	github.com/aws/jsii-runtime-go v1.84.0
+	github.com/sirupsen/logrus v1.9.3
+	sigs.k8s.io/yaml v1.3.0
 )
 
 require (
@@ -19,4 +21,5 @@ require (
 	golang.org/x/mod v0.10.0 // indirect
 	golang.org/x/sys v0.8.0 // indirect
 	golang.org/x/tools v0.9.3 // indirect
+	gopkg.in/yaml.v2 v2.4.0 // indirect
 )
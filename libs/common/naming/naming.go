@@ -0,0 +1,108 @@
+// Package naming builds the physical names of the AWS resources created by the service Acorns, so they fit the
+// length and character limits of each AWS service.
+package naming
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Limits are the length and character limits of the names of an AWS resource type
+type Limits struct {
+	// MaxLength is the maximum length of a name, including its suffix
+	MaxLength int
+	// Invalid matches the characters that are not allowed in a name, they are replaced with hyphens
+	Invalid *regexp.Regexp
+	// HashLength is the length of the hash suffix of names that had to be truncated. Without one, names are cut
+	// off at MaxLength.
+	HashLength int
+	// Suffix is the suffix every name must end with
+	Suffix string
+}
+
+var (
+	// ElastiCache limits the names of ElastiCache clusters, replication groups and subnet groups. The names
+	// already end with a hash of the external ID and are cut off without a hash suffix, so the names of existing
+	// clusters do not change.
+	ElastiCache = Limits{MaxLength: 40, Invalid: regexp.MustCompile(`[^a-zA-Z0-9-]`)}
+	// IAMRole limits the names of IAM roles
+	IAMRole = Limits{MaxLength: 64, Invalid: regexp.MustCompile(`[^\w+=,.@-]`), HashLength: 8}
+	// LambdaFunction limits the names of Lambda functions
+	LambdaFunction = Limits{MaxLength: 64, Invalid: regexp.MustCompile(`[^a-zA-Z0-9_-]`), HashLength: 8}
+	// KMSAlias limits the names of KMS aliases, without the alias/ prefix
+	KMSAlias = Limits{MaxLength: 256 - len("alias/"), Invalid: regexp.MustCompile(`[^a-zA-Z0-9/_-]`), HashLength: 8}
+	// SQSQueue limits the names of standard SQS queues
+	SQSQueue = Limits{MaxLength: 80, Invalid: regexp.MustCompile(`[^a-zA-Z0-9_-]`), HashLength: 8}
+	// SQSFIFOQueue limits the names of FIFO SQS queues, which must end with .fifo
+	SQSFIFOQueue = SQSQueue.WithSuffix(".fifo")
+)
+
+// WithSuffix returns the limits for names that must end with the suffix
+func (l Limits) WithSuffix(suffix string) Limits {
+	l.Suffix = suffix
+	return l
+}
+
+// Name joins the non-empty parts with hyphens and fits the result into the limits
+func (l Limits) Name(parts ...string) string {
+	var nonEmpty []string
+	for _, p := range parts {
+		if p != "" {
+			nonEmpty = append(nonEmpty, p)
+		}
+	}
+	return l.Fit(strings.Join(nonEmpty, "-"))
+}
+
+// Fit replaces the characters that are not allowed with hyphens, adds the suffix when it is missing and
+// truncates names that are too long. A truncated name ends with a hash of the whole name, so names that only
+// differ after the cut stay unique. Names that already fit are returned unchanged.
+func (l Limits) Fit(name string) string {
+	name = strings.TrimSuffix(name, l.Suffix)
+	if l.Invalid != nil {
+		name = l.Invalid.ReplaceAllString(name, "-")
+	}
+
+	maxLength := l.MaxLength - len(l.Suffix)
+	if len(name) > maxLength {
+		if l.HashLength > 0 {
+			sum := sha256.Sum256([]byte(name))
+			hash := hex.EncodeToString(sum[:])[:l.HashLength]
+			name = strings.TrimRight(name[:maxLength-l.HashLength-1], "-") + "-" + hash
+		} else {
+			name = name[:maxLength]
+		}
+	}
+	return name + l.Suffix
+}
+
+// Acorn identifies the Acorn a stack is deployed for
+type Acorn struct {
+	Name       string
+	Project    string
+	Account    string
+	ExternalID string
+}
+
+// AcornFromEnv returns the Acorn set in the environment of the service job
+func AcornFromEnv() Acorn {
+	return Acorn{
+		Name:       os.Getenv("ACORN_NAME"),
+		Project:    os.Getenv("ACORN_PROJECT"),
+		Account:    os.Getenv("ACORN_ACCOUNT"),
+		ExternalID: os.Getenv("ACORN_EXTERNAL_ID"),
+	}
+}
+
+// ResourceName returns a name made of the Acorn name, account and project, unique within an AWS account
+func (a Acorn) ResourceName(l Limits) string {
+	return l.Name(a.Name, a.Account, a.Project)
+}
+
+// ExternalIDName returns a name made of the external ID of the Acorn
+func (a Acorn) ExternalIDName(l Limits) string {
+	return l.Fit(a.ExternalID)
+}
@@ -0,0 +1,110 @@
+package naming
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFit(t *testing.T) {
+	long := strings.Repeat("a", 100)
+
+	tests := []struct {
+		name   string
+		limits Limits
+		in     string
+		want   string
+	}{
+		{
+			name:   "fits",
+			limits: IAMRole,
+			in:     "my-app-my-project-1a2b3c",
+			want:   "my-app-my-project-1a2b3c",
+		},
+		{
+			name:   "invalid characters",
+			limits: KMSAlias,
+			in:     "my.app-acct-my.project",
+			want:   "my-app-acct-my-project",
+		},
+		{
+			name:   "lambda function",
+			limits: LambdaFunction,
+			in:     "acorn-rds-rotation-my.app-my-project-1a2b3c-admin",
+			want:   "acorn-rds-rotation-my-app-my-project-1a2b3c-admin",
+		},
+		{
+			name:   "truncated with hash",
+			limits: IAMRole,
+			in:     long,
+			want:   strings.Repeat("a", 55) + "-" + "28165978",
+		},
+		{
+			name:   "truncated without hash",
+			limits: ElastiCache,
+			in:     long,
+			want:   strings.Repeat("a", 40),
+		},
+		{
+			name:   "suffix added",
+			limits: SQSFIFOQueue,
+			in:     "orders",
+			want:   "orders.fifo",
+		},
+		{
+			name:   "suffix kept",
+			limits: SQSFIFOQueue,
+			in:     "orders.fifo",
+			want:   "orders.fifo",
+		},
+		{
+			name:   "truncated before suffix",
+			limits: SQSFIFOQueue,
+			in:     long + ".fifo",
+			want:   strings.Repeat("a", 66) + "-" + "28165978" + ".fifo",
+		},
+		{
+			name:   "no trailing hyphen before hash",
+			limits: IAMRole,
+			in:     strings.Repeat("a", 54) + "-" + long,
+			want:   strings.Repeat("a", 54) + "-" + "86d2bf73",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.limits.Fit(tt.in)
+			if got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+			if len(got) > tt.limits.MaxLength {
+				t.Errorf("%q is longer than %d characters", got, tt.limits.MaxLength)
+			}
+			if again := tt.limits.Fit(got); again != got {
+				t.Errorf("expected fitted name %q to be unchanged, got %q", got, again)
+			}
+		})
+	}
+}
+
+func TestFitUnique(t *testing.T) {
+	prefix := strings.Repeat("project", 10)
+	a := IAMRole.Fit(prefix + "-first")
+	b := IAMRole.Fit(prefix + "-second")
+	if a == b {
+		t.Errorf("expected names truncated to %q to differ", a)
+	}
+}
+
+func TestAcornResourceName(t *testing.T) {
+	a := Acorn{Name: "my.app", Account: "acct", Project: "my-project", ExternalID: "my-app-my-project-1a2b3c"}
+
+	if got, want := a.ResourceName(KMSAlias), "my-app-acct-my-project"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+	if got, want := a.ExternalIDName(IAMRole), "my-app-my-project-1a2b3c"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+	if got, want := (Acorn{Name: "app"}).ResourceName(KMSAlias), "app"; got != want {
+		t.Errorf("expected empty parts to be skipped, got %q", got)
+	}
+}
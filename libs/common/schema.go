@@ -0,0 +1,207 @@
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var acornfileArgRegex = regexp.MustCompile(`^\t(\w+)\s*:\s*(.*)$`)
+
+// JSONSchema returns the JSON Schema of the config decoded into props by DecodeConfig. Validation rules are read
+// from the jsonschema struct tag of the fields, a comma separated list of enum=a|b|c, minimum=n, maximum=n and
+// pattern=regex rules. Patterns cannot contain commas.
+func JSONSchema(props any) map[string]any {
+	schema := typeSchema(reflect.TypeOf(props))
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	return schema
+}
+
+func typeSchema(t reflect.Type) map[string]any {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return typeSchema(t.Elem())
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]any{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer", "minimum": 0.0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": typeSchema(t.Elem())}
+	case reflect.Struct:
+		properties := map[string]any{}
+		for name, f := range configFields(t) {
+			schema := typeSchema(f.Type)
+			applySchemaRules(schema, f.Tag.Get("jsonschema"))
+			properties[name] = schema
+		}
+		return map[string]any{"type": "object", "properties": properties, "additionalProperties": false}
+	}
+	return map[string]any{}
+}
+
+func applySchemaRules(schema map[string]any, rules string) {
+	if rules == "" {
+		return
+	}
+	// The rules of a list apply to its items
+	if items, ok := schema["items"].(map[string]any); ok {
+		applySchemaRules(items, rules)
+		return
+	}
+	numeric := schema["type"] == "integer" || schema["type"] == "number"
+	for _, rule := range strings.Split(rules, ",") {
+		key, value, _ := strings.Cut(rule, "=")
+		switch key {
+		case "enum":
+			var enum []any
+			for _, v := range strings.Split(value, "|") {
+				if n, err := strconv.ParseFloat(v, 64); numeric && err == nil {
+					enum = append(enum, n)
+				} else {
+					enum = append(enum, v)
+				}
+			}
+			schema["enum"] = enum
+		case "minimum", "maximum":
+			if n, err := strconv.ParseFloat(value, 64); err == nil {
+				schema[key] = n
+			}
+		case "pattern":
+			schema[key] = value
+		}
+	}
+}
+
+// AcornfileArgs returns the args of an Acornfile with the literal of their default value
+func AcornfileArgs(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	_, block, found := strings.Cut(string(data), "\nargs: {\n")
+	if !found {
+		return nil, nil
+	}
+	block, _, _ = strings.Cut(block, "\n}\n")
+
+	args := map[string]string{}
+	for _, line := range strings.Split(block, "\n") {
+		if m := acornfileArgRegex.FindStringSubmatch(line); m != nil {
+			args[m[1]] = m[2]
+		}
+	}
+	return args, nil
+}
+
+// ValidateAcornfileArgs checks the args of an Acornfile against the JSON Schema of props. Every arg must be a
+// config field, and its default value must be valid for the field.
+func ValidateAcornfileArgs(props any, path string) error {
+	args, err := AcornfileArgs(path)
+	if err != nil {
+		return err
+	}
+
+	properties, _ := JSONSchema(props)["properties"].(map[string]any)
+
+	var errs []error
+	for _, name := range sortedKeys(args) {
+		property, ok := properties[name].(map[string]any)
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: arg %s is not a config field", path, name))
+			continue
+		}
+
+		var value any
+		if err := json.Unmarshal([]byte(args[name]), &value); err != nil {
+			// Not a plain literal, the value is computed by the Acornfile
+			continue
+		}
+		for _, err := range validateSchemaValue(property, value, name) {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validateSchemaValue validates a decoded JSON value against the subset of JSON Schema produced by JSONSchema
+func validateSchemaValue(schema map[string]any, value any, path string) []error {
+	var errs []error
+	switch schema["type"] {
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return []error{fmt.Errorf("%s must be of type boolean", path)}
+		}
+	case "integer", "number":
+		n, ok := value.(float64)
+		if !ok || (schema["type"] == "integer" && n != math.Trunc(n)) {
+			return []error{fmt.Errorf("%s must be of type %s", path, schema["type"])}
+		}
+		if minimum, ok := schema["minimum"].(float64); ok && n < minimum {
+			errs = append(errs, fmt.Errorf("%s must be at least %v", path, minimum))
+		}
+		if maximum, ok := schema["maximum"].(float64); ok && n > maximum {
+			errs = append(errs, fmt.Errorf("%s must be at most %v", path, maximum))
+		}
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return []error{fmt.Errorf("%s must be of type string", path)}
+		}
+		// An empty string leaves the field unset
+		if pattern, ok := schema["pattern"].(string); ok && s != "" && !regexp.MustCompile(pattern).MatchString(s) {
+			errs = append(errs, fmt.Errorf("%s must match %s", path, pattern))
+		}
+	case "array":
+		items, ok := value.([]any)
+		if !ok {
+			return []error{fmt.Errorf("%s must be of type array", path)}
+		}
+		itemSchema, _ := schema["items"].(map[string]any)
+		for i, item := range items {
+			errs = append(errs, validateSchemaValue(itemSchema, item, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			return []error{fmt.Errorf("%s must be of type object", path)}
+		}
+		properties, _ := schema["properties"].(map[string]any)
+		for _, k := range sortedKeys(object) {
+			if property, ok := properties[k].(map[string]any); ok {
+				errs = append(errs, validateSchemaValue(property, object[k], path+"."+k)...)
+			} else if additional, ok := schema["additionalProperties"].(map[string]any); ok {
+				errs = append(errs, validateSchemaValue(additional, object[k], path+"."+k)...)
+			} else {
+				errs = append(errs, fmt.Errorf("%s.%s is not a config field", path, k))
+			}
+		}
+	}
+
+	if enum, ok := schema["enum"].([]any); ok && value != "" {
+		found := false
+		for _, e := range enum {
+			if reflect.DeepEqual(e, value) {
+				found = true
+			}
+		}
+		if !found {
+			errs = append(errs, fmt.Errorf("%s must be one of %v", path, enum))
+		}
+	}
+	return errs
+}
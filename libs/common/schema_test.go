@@ -0,0 +1,115 @@
+package common
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type testSchemaProps struct {
+	Name     string            `json:"name" jsonschema:"pattern=^[a-z]+$"`
+	Size     string            `json:"size" jsonschema:"enum=small|large"`
+	Days     int               `json:"days" jsonschema:"minimum=1,maximum=30"`
+	Capacity float64           `json:"capacity" jsonschema:"enum=0.5|1"`
+	Accounts []string          `json:"accounts" jsonschema:"pattern=^[0-9]{12}$"`
+	Tags     map[string]string `json:"tags"`
+	Policy   map[string]any    `json:"policy"`
+	Enabled  bool              `json:"enabled"`
+	IngressProps
+}
+
+func TestJSONSchema(t *testing.T) {
+	out, err := json.Marshal(JSONSchema(&testSchemaProps{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		`"$schema":"https://json-schema.org/draft/2020-12/schema"`,
+		`"additionalProperties":false`,
+		`"name":{"pattern":"^[a-z]+$","type":"string"}`,
+		`"size":{"enum":["small","large"],"type":"string"}`,
+		`"days":{"maximum":30,"minimum":1,"type":"integer"}`,
+		`"capacity":{"enum":[0.5,1],"type":"number"}`,
+		`"accounts":{"items":{"pattern":"^[0-9]{12}$","type":"string"},"type":"array"}`,
+		`"tags":{"additionalProperties":{"type":"string"},"type":"object"}`,
+		`"policy":{"additionalProperties":{},"type":"object"}`,
+		`"enabled":{"type":"boolean"}`,
+		`"allowedCidrs":{"items":{"type":"string"},"type":"array"}`,
+	} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("expected schema to contain %s, got %s", want, out)
+		}
+	}
+}
+
+func TestValidateAcornfileArgs(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        string
+		errContains []string
+	}{
+		{
+			name: "valid args",
+			args: `
+	// Name of the thing.
+	name: ""
+	size: "small"
+	days: 7
+	capacity: 0.5
+	accounts: []
+	tags: {}
+	policy: {}
+	enabled: true
+	allowedSecurityGroupIds: ["sg-01234567"]
+`,
+		},
+		{
+			name: "invalid args",
+			args: `
+	nme: ""
+	size: "medium"
+	days: 0
+	capacity: 1.5
+	accounts: ["1234"]
+	tags: {key: 1}
+	enabled: "true"
+`,
+			errContains: []string{
+				"arg nme is not a config field",
+				"size must be one of [small large]",
+				"days must be at least 1",
+				"capacity must be one of [0.5 1]",
+				"accounts[0] must match ^[0-9]{12}$",
+				"enabled must be of type boolean",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "Acornfile")
+			if err := os.WriteFile(path, []byte("name: \"Test\"\n\nargs: {"+tt.args+"}\n\nservices: {}\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			err := ValidateAcornfileArgs(&testSchemaProps{}, path)
+			if len(tt.errContains) == 0 {
+				if err != nil {
+					t.Errorf("unexpected error: %s", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected errors %q, got nil", tt.errContains)
+			}
+			for _, e := range tt.errContains {
+				if !strings.Contains(err.Error(), e) {
+					t.Errorf("expected error to contain %q, got %q", e, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/constructs-go/constructs/v10"
+	"github.com/aws/jsii-runtime-go"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// ReasonInvalidConfig is the StackError reason for config that cannot be read, decoded or validated
+	ReasonInvalidConfig = "InvalidConfig"
+	// ReasonStackFailed is the StackError reason for errors returned while building the stack
+	ReasonStackFailed = "StackFailed"
+)
+
+// TerminationLog is the file RunStack writes its StackError to, so the reason shows up in the status of the
+// Acorn job.
+var TerminationLog = "/dev/termination-log"
+
+// StackProps is implemented by the config of a service stack run with RunStack. The config file is decoded into
+// it, then SetDefaults fills in the unset fields and Validate reports everything that is wrong with the result.
+type StackProps interface {
+	SetDefaults()
+	Validate() error
+	// GetTags returns the user tags to apply to all resources of the stack
+	GetTags() map[string]string
+}
+
+// StackError is the structured error RunStack writes to the termination log
+type StackError struct {
+	Reason string   `json:"reason"`
+	Errors []string `json:"errors"`
+}
+
+func (se *StackError) Error() string {
+	msg := se.Reason
+	for _, err := range se.Errors {
+		msg += "\n" + err
+	}
+	return msg
+}
+
+// RunStack builds and synthesizes the stack of a service Acorn. The props are read from the config file,
+// defaulted and validated before newStack adds the stack to the Acorn tagged app. Every error is logged and
+// written to the termination log as a StackError before exiting. The --config flag sets the config file, see
+// ConfigFile. Run with the schema argument, it prints the JSON Schema of the config instead.
+func RunStack[T StackProps](props T, newStack func(scope constructs.Construct, props T) error) {
+	flags := flag.NewFlagSet(filepath.Base(os.Args[0]), flag.ExitOnError)
+	flags.StringVar(&ConfigFile, "config", ConfigFile, "path of the JSON or YAML config file, - to read it from stdin")
+	_ = flags.Parse(os.Args[1:])
+
+	if flags.Arg(0) == "schema" {
+		out, err := json.MarshalIndent(JSONSchema(props), "", "  ")
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		fmt.Println(string(out))
+		return
+	}
+
+	defer jsii.Close()
+
+	app := NewAcornTaggedApp(nil)
+
+	conf, err := ConfigBytes()
+	if err == nil {
+		err = runStack(app, conf, props, newStack)
+	} else {
+		err = &StackError{Reason: ReasonInvalidConfig, Errors: errorStrings(err)}
+	}
+
+	var se *StackError
+	if errors.As(err, &se) {
+		for _, e := range se.Errors {
+			logrus.WithField("reason", se.Reason).Error(e)
+		}
+		if out, err := json.Marshal(se); err == nil {
+			if err := os.WriteFile(TerminationLog, out, 0644); err != nil {
+				logrus.WithError(err).Warn("failed to write the termination log")
+			}
+		}
+		jsii.Close()
+		os.Exit(1)
+	}
+
+	app.Synth(nil)
+}
+
+func runStack[T StackProps](scope constructs.Construct, conf []byte, props T, newStack func(scope constructs.Construct, props T) error) error {
+	if err := PrepareStack(scope, conf, props); err != nil {
+		return err
+	}
+	if err := newStack(scope, props); err != nil {
+		return &StackError{Reason: ReasonStackFailed, Errors: errorStrings(err)}
+	}
+	return nil
+}
+
+// PrepareStack decodes the config into props, defaults and validates them, and applies the user tags to the
+// scope, as configured by a TagPropagator. RunStack calls it before building the stack, the errors are StackErrors
+// with the InvalidConfig reason.
+func PrepareStack(scope constructs.Construct, conf []byte, props StackProps) error {
+	if err := DecodeConfig(conf, props); err != nil {
+		return &StackError{Reason: ReasonInvalidConfig, Errors: errorStrings(err)}
+	}
+
+	props.SetDefaults()
+	tags := SanitizeTags(props.GetTags())
+	if err := errors.Join(props.Validate(), ValidateTags(tags)); err != nil {
+		return &StackError{Reason: ReasonInvalidConfig, Errors: errorStrings(err)}
+	}
+
+	var tagProps *awscdk.TagProps
+	if tp, ok := any(props).(TagPropagator); ok {
+		tagProps = tp.TagProps()
+	}
+	AppendScopedTagsWithProps(scope, tags, tagProps)
+	return nil
+}
+
+// errorStrings flattens joined errors, so each of them is reported on its own
+func errorStrings(err error) []string {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		var errs []string
+		for _, e := range joined.Unwrap() {
+			errs = append(errs, errorStrings(e)...)
+		}
+		return errs
+	}
+	return []string{err.Error()}
+}
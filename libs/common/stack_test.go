@@ -0,0 +1,118 @@
+package common
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/constructs-go/constructs/v10"
+)
+
+type testStackProps struct {
+	Name string            `json:"name"`
+	Size int               `json:"size"`
+	Tags map[string]string `json:"tags"`
+}
+
+func (p *testStackProps) SetDefaults() {
+	if p.Size == 0 {
+		p.Size = 1
+	}
+}
+
+func (p *testStackProps) Validate() error {
+	var errs []error
+	if p.Name == "" {
+		errs = append(errs, errors.New("name is required"))
+	}
+	if p.Size > 10 {
+		errs = append(errs, errors.New("size must be at most 10"))
+	}
+	return errors.Join(errs...)
+}
+
+func (p *testStackProps) GetTags() map[string]string {
+	return p.Tags
+}
+
+func TestRunStack(t *testing.T) {
+	tests := []struct {
+		name     string
+		conf     string
+		stackErr error
+		want     *StackError
+		wantSize int
+	}{
+		{
+			name:     "defaults and builds the stack",
+			conf:     `{"name": "test", "tags": {"team": "db"}}`,
+			wantSize: 1,
+		},
+		{
+			name: "invalid json",
+			conf: `{"name": 1}`,
+			want: &StackError{
+				Reason: ReasonInvalidConfig,
+				Errors: []string{"json: cannot unmarshal number into Go struct field testStackProps.name of type string"},
+			},
+		},
+		{
+			name: "every validation error is reported",
+			conf: `{"size": 11}`,
+			want: &StackError{
+				Reason: ReasonInvalidConfig,
+				Errors: []string{"name is required", "size must be at most 10"},
+			},
+		},
+		{
+			name: "tags are validated with the props",
+			conf: `{"tags": {"acorn.io/managed": "false"}}`,
+			want: &StackError{
+				Reason: ReasonInvalidConfig,
+				Errors: []string{"name is required", `tags: key "acorn.io/managed" is managed by Acorn and cannot be set`},
+			},
+		},
+		{
+			name:     "stack error",
+			conf:     `{"name": "test"}`,
+			stackErr: errors.New("failed to build"),
+			want: &StackError{
+				Reason: ReasonStackFailed,
+				Errors: []string{"failed to build"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			props := &testStackProps{}
+			built := false
+			err := runStack(awscdk.NewApp(nil), []byte(tt.conf), props, func(scope constructs.Construct, props *testStackProps) error {
+				built = true
+				return tt.stackErr
+			})
+
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				if !built {
+					t.Error("expected the stack to be built")
+				}
+				if props.Size != tt.wantSize {
+					t.Errorf("expected size %d, got %d", tt.wantSize, props.Size)
+				}
+				return
+			}
+
+			var se *StackError
+			if !errors.As(err, &se) {
+				t.Fatalf("expected a StackError, got %v", err)
+			}
+			if !reflect.DeepEqual(se, tt.want) {
+				t.Errorf("expected %+v, got %+v", tt.want, se)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+// Package stacktest synthesizes service stacks offline, with a fixed environment and VPC, and compares their
+// templates with golden files.
+package stacktest
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/acorn-io/services/aws/libs/common"
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/jsii-runtime-go"
+)
+
+const (
+	// Account is the AWS account of the test stacks
+	Account = "123456789012"
+	// Region is the AWS region of the test stacks
+	Region = "us-east-1"
+	// VpcID is the VPC the lookups of the test app resolve to
+	VpcID = "vpc-0123456789abcdef0"
+	// ExternalID is the Acorn external ID set by Setenv
+	ExternalID = "my-app-my-project-0123456789ab"
+)
+
+// The flag is only defined in packages that import stacktest, so UPDATE_GOLDEN can be set instead when running
+// the tests of a whole module
+var update = flag.Bool("update", os.Getenv("UPDATE_GOLDEN") != "", "update the golden templates in testdata")
+
+// Setenv sets the environment of the service job for the duration of the test
+func Setenv(t *testing.T) {
+	t.Setenv("CDK_DEFAULT_ACCOUNT", Account)
+	t.Setenv("CDK_DEFAULT_REGION", Region)
+	t.Setenv("VPC_ID", VpcID)
+	t.Setenv("ACORN_ACCOUNT", "my-account")
+	t.Setenv("ACORN_NAME", "my-app")
+	t.Setenv("ACORN_PROJECT", "my-project")
+	t.Setenv("ACORN_EXTERNAL_ID", ExternalID)
+}
+
+// StackProps returns stack props for the test account and region
+func StackProps() awscdk.StackProps {
+	return awscdk.StackProps{
+		Synthesizer: awscdk.NewDefaultStackSynthesizer(&awscdk.DefaultStackSynthesizerProps{
+			GenerateBootstrapVersionRule: jsii.Bool(false),
+		}),
+		Env: &awscdk.Environment{
+			Account: jsii.String(Account),
+			Region:  jsii.String(Region),
+		},
+	}
+}
+
+// NewApp returns an app whose VPC lookups of VpcID resolve to a VPC with a public and a private subnet in two
+// availability zones, instead of the dummy VPC CDK uses when the lookup is missing.
+func NewApp() awscdk.App {
+	key := fmt.Sprintf("vpc-provider:account=%s:filter.vpc-id=%s:region=%s:returnAsymmetricSubnets=true", Account, VpcID, Region)
+	return awscdk.NewApp(&awscdk.AppProps{
+		AnalyticsReporting: jsii.Bool(false),
+		Context: &map[string]interface{}{
+			key: map[string]interface{}{
+				"vpcId":             VpcID,
+				"vpcCidrBlock":      "10.0.0.0/16",
+				"availabilityZones": []interface{}{},
+				"subnetGroups": []interface{}{
+					subnetGroup("Public", "Public", "10.0.0.0/20", "10.0.16.0/20"),
+					subnetGroup("Private", "Private", "10.0.128.0/20", "10.0.144.0/20"),
+				},
+			},
+		},
+	})
+}
+
+func subnetGroup(name, subnetType string, cidrs ...string) map[string]interface{} {
+	var subnets []interface{}
+	for i, cidr := range cidrs {
+		az := Region + string(rune('a'+i))
+		subnets = append(subnets, map[string]interface{}{
+			"subnetId":         fmt.Sprintf("subnet-%s-%s", name, az),
+			"cidr":             cidr,
+			"availabilityZone": az,
+			"routeTableId":     fmt.Sprintf("rtb-%s-%s", name, az),
+		})
+	}
+	return map[string]interface{}{"name": name, "type": subnetType, "subnets": subnets}
+}
+
+// AcornfileConfig returns the JSON config an Acornfile renders with the default values of its args, with the
+// top-level fields of the JSON overrides replacing them. Args without a literal default are left out.
+func AcornfileConfig(t *testing.T, path string, overrides string) string {
+	t.Helper()
+
+	args, err := common.AcornfileArgs(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := map[string]json.RawMessage{}
+	for name, value := range args {
+		if json.Valid([]byte(value)) {
+			conf[name] = json.RawMessage(value)
+		}
+	}
+	if overrides != "" {
+		if err := json.Unmarshal([]byte(overrides), &conf); err != nil {
+			t.Fatalf("invalid overrides: %s", err)
+		}
+	}
+
+	out, err := json.Marshal(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+// NewAppWithConfig prepares props with common.PrepareStack, as RunStack does, in the environment set by Setenv: the
+// JSON config is decoded into props, defaulted and validated, and the user tags are applied to the returned app
+// from NewApp.
+func NewAppWithConfig[T common.StackProps](t *testing.T, conf string, props T) awscdk.App {
+	t.Helper()
+	Setenv(t)
+
+	app := NewApp()
+	if err := common.PrepareStack(app, []byte(conf), props); err != nil {
+		t.Fatalf("invalid config: %s", err)
+	}
+	return app
+}
+
+// AssertGolden compares the template of the stack with the golden file of the test, testdata/<test name>.json.
+// Run the tests with -update, or with UPDATE_GOLDEN set, to write the templates instead.
+func AssertGolden(t *testing.T, stack awscdk.Stack) {
+	t.Helper()
+
+	got, err := json.MarshalIndent(assertions.Template_FromStack(stack, nil).ToJSON(), "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = append(got, '\n')
+
+	path := filepath.Join("testdata", filepath.FromSlash(t.Name())+".json")
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%s, run the tests with -update to create it", err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("template does not match %s, run the tests with -update if the change is expected:\n%s", path, diff(string(want), string(got)))
+	}
+}
+
+// diff returns the lines around the first difference of two templates
+func diff(want, got string) string {
+	wantLines, gotLines := strings.Split(want, "\n"), strings.Split(got, "\n")
+	i := 0
+	for i < len(wantLines) && i < len(gotLines) && wantLines[i] == gotLines[i] {
+		i++
+	}
+
+	start := i - 3
+	if start < 0 {
+		start = 0
+	}
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "first difference at line %d\n", i+1)
+	for j := start; j < i+3 && j < len(wantLines); j++ {
+		fmt.Fprintf(b, "- %s\n", wantLines[j])
+	}
+	for j := start; j < i+3 && j < len(gotLines); j++ {
+		fmt.Fprintf(b, "+ %s\n", gotLines[j])
+	}
+	return b.String()
+}
@@ -0,0 +1,67 @@
+package stacktest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/acorn-io/services/aws/libs/common"
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsec2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssns"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssqs"
+	"github.com/aws/jsii-runtime-go"
+)
+
+type propagatingProps struct {
+	Tags map[string]string `json:"tags"`
+	common.TagPropagationProps
+}
+
+func (p *propagatingProps) SetDefaults()               {}
+func (p *propagatingProps) Validate() error            { return nil }
+func (p *propagatingProps) GetTags() map[string]string { return p.Tags }
+
+func TestNewAppVpcLookup(t *testing.T) {
+	app := NewApp()
+	props := StackProps()
+	stack := awscdk.NewStack(app, jsii.String("Stack"), &props)
+	vpc := awsec2.Vpc_FromLookup(stack, jsii.String("VPC"), &awsec2.VpcLookupOptions{VpcId: jsii.String(VpcID)})
+
+	if missing := app.Synth(nil).Manifest().Missing; missing != nil && len(*missing) > 0 {
+		t.Fatalf("expected the VPC lookup to be resolved, missing %s", *(*missing)[0].Key)
+	}
+	if got := *vpc.VpcId(); got != VpcID {
+		t.Errorf("expected VPC %s, got %s", VpcID, got)
+	}
+	if got := len(*vpc.PrivateSubnets()); got != 2 {
+		t.Errorf("expected 2 private subnets, got %d", got)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	got := diff("a\nb\nc\n", "a\nx\nc\n")
+	for _, want := range []string{"first difference at line 2", "- b", "+ x"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected diff to contain %q, got %q", want, got)
+		}
+	}
+}
+
+func TestNewAppWithConfigTagPropagator(t *testing.T) {
+	props := &propagatingProps{}
+	app := NewAppWithConfig(t, `{"tags": {"team": "platform"}, "tagsExcludeResourceTypes": ["AWS::SQS::Queue"]}`, props)
+	stackProps := StackProps()
+	stack := awscdk.NewStack(app, jsii.String("Stack"), &stackProps)
+	awssqs.NewQueue(stack, jsii.String("Queue"), nil)
+	awssns.NewTopic(stack, jsii.String("Topic"), nil)
+
+	// The tags are applied as RunStack applies them, with the TagProps of the props
+	template := assertions.Template_FromStack(stack, nil)
+	template.HasResourceProperties(jsii.String("AWS::SNS::Topic"), map[string]interface{}{
+		"Tags": []interface{}{map[string]interface{}{"Key": "team", "Value": "platform"}},
+	})
+	template.HasResourceProperties(jsii.String("AWS::SQS::Queue"), map[string]interface{}{
+		"Tags": assertions.Match_Absent(),
+	})
+}
@@ -0,0 +1,122 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/constructs-go/constructs/v10"
+	"github.com/aws/jsii-runtime-go"
+)
+
+const (
+	// AcornTagPrefix is the prefix of the tags Acorn manages, users cannot set tags with it
+	AcornTagPrefix = "acorn.io/"
+	// MaxTags is the maximum number of tags of an AWS resource, including the ones managed by Acorn
+	MaxTags = 50
+
+	maxTagKeyLength   = 128
+	maxTagValueLength = 256
+)
+
+var (
+	tagCharsRegex     = regexp.MustCompile(`^[\p{L}\p{Z}\p{N}_.:/=+\-@]*$`)
+	resourceTypeRegex = regexp.MustCompile(`^[A-Za-z0-9]+::[A-Za-z0-9]+::[A-Za-z0-9]+$`)
+)
+
+// TagPropagator is implemented by StackProps that need to configure how the user tags are applied, for resource
+// types that only pass tags on to the resources they create when told to, like the instances of an auto scaling
+// group, or to limit the resource types that are tagged.
+type TagPropagator interface {
+	TagProps() *awscdk.TagProps
+}
+
+// TagPropagationProps configures the resource types the user tags are applied to. StackProps embedding it
+// implement TagPropagator. Without resource types, the tags are applied to every taggable resource.
+type TagPropagationProps struct {
+	TagsIncludeResourceTypes []string `json:"tagsIncludeResourceTypes" yaml:"tagsIncludeResourceTypes" jsonschema:"pattern=^[A-Za-z0-9]+::[A-Za-z0-9]+::[A-Za-z0-9]+$"`
+	TagsExcludeResourceTypes []string `json:"tagsExcludeResourceTypes" yaml:"tagsExcludeResourceTypes" jsonschema:"pattern=^[A-Za-z0-9]+::[A-Za-z0-9]+::[A-Za-z0-9]+$"`
+}
+
+// Validate checks that the resource types are CloudFormation resource types, like AWS::S3::Bucket
+func (tp *TagPropagationProps) Validate() error {
+	var errs []error
+	for _, t := range tp.TagsIncludeResourceTypes {
+		if !resourceTypeRegex.MatchString(t) {
+			errs = append(errs, fmt.Errorf("tagsIncludeResourceTypes: %q is not a valid resource type", t))
+		}
+	}
+	for _, t := range tp.TagsExcludeResourceTypes {
+		if !resourceTypeRegex.MatchString(t) {
+			errs = append(errs, fmt.Errorf("tagsExcludeResourceTypes: %q is not a valid resource type", t))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// TagProps returns the tag props limiting the tags to the included resource types, minus the excluded ones
+func (tp *TagPropagationProps) TagProps() *awscdk.TagProps {
+	props := &awscdk.TagProps{}
+	if len(tp.TagsIncludeResourceTypes) > 0 {
+		props.IncludeResourceTypes = jsii.Strings(tp.TagsIncludeResourceTypes...)
+	}
+	if len(tp.TagsExcludeResourceTypes) > 0 {
+		props.ExcludeResourceTypes = jsii.Strings(tp.TagsExcludeResourceTypes...)
+	}
+	return props
+}
+
+// SanitizeTags returns the tags with the surrounding whitespace of keys and values removed
+func SanitizeTags(tags map[string]string) map[string]string {
+	sanitized := make(map[string]string, len(tags))
+	for k, v := range tags {
+		sanitized[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return sanitized
+}
+
+// ValidateTags reports every user tag that AWS would reject, and every tag that would override a tag managed
+// by Acorn.
+func ValidateTags(tags map[string]string) error {
+	var errs []error
+	if len(tags)+len(acornTags) > MaxTags {
+		errs = append(errs, fmt.Errorf("tags: at most %d tags can be set, %d of the %d tags of a resource are managed by Acorn", MaxTags-len(acornTags), len(acornTags), MaxTags))
+	}
+
+	for _, k := range sortedKeys(tags) {
+		switch {
+		case k == "" || utf8.RuneCountInString(k) > maxTagKeyLength:
+			errs = append(errs, fmt.Errorf("tags: key %q must be 1 to %d characters", k, maxTagKeyLength))
+		case strings.HasPrefix(strings.ToLower(k), "aws:"):
+			errs = append(errs, fmt.Errorf("tags: key %q uses the reserved aws: prefix", k))
+		case strings.HasPrefix(strings.ToLower(k), AcornTagPrefix):
+			errs = append(errs, fmt.Errorf("tags: key %q is managed by Acorn and cannot be set", k))
+		case !tagCharsRegex.MatchString(k):
+			errs = append(errs, fmt.Errorf("tags: key %q can only contain letters, numbers, spaces and _ . : / = + - @", k))
+		}
+
+		v := tags[k]
+		if utf8.RuneCountInString(v) > maxTagValueLength {
+			errs = append(errs, fmt.Errorf("tags: value of %q must be at most %d characters", k, maxTagValueLength))
+		}
+		if !tagCharsRegex.MatchString(v) {
+			errs = append(errs, fmt.Errorf("tags: value of %q can only contain letters, numbers, spaces and _ . : / = + - @", k))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// AppendScopedTagsWithProps applies the tags to all taggable resources in the scope, as configured by the tag
+// props.
+func AppendScopedTagsWithProps(scope constructs.Construct, tags map[string]string, props *awscdk.TagProps) {
+	if props == nil {
+		props = &awscdk.TagProps{}
+	}
+	scopedTags := awscdk.Tags_Of(scope)
+	for k, v := range tags {
+		scopedTags.Add(jsii.String(k), jsii.String(v), props)
+	}
+}
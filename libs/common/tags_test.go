@@ -0,0 +1,138 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSanitizeTags(t *testing.T) {
+	tags := SanitizeTags(map[string]string{" team ": " db\n"})
+	if len(tags) != 1 || tags["team"] != "db" {
+		t.Errorf("expected the surrounding whitespace to be removed, got %q", tags)
+	}
+}
+
+func TestValidateTags(t *testing.T) {
+	tooMany := map[string]string{}
+	for i := 0; i < MaxTags-len(acornTags)+1; i++ {
+		tooMany[fmt.Sprintf("tag%d", i)] = "value"
+	}
+
+	tests := []struct {
+		name        string
+		tags        map[string]string
+		errContains string
+	}{
+		{
+			name: "valid tags",
+			tags: map[string]string{"team": "db", "cost-center": "", "app:env/name": "prod = 1 + @ä", "Größe": "日本"},
+		},
+		{
+			name:        "too many tags",
+			tags:        tooMany,
+			errContains: fmt.Sprintf("tags: at most %d tags can be set", MaxTags-len(acornTags)),
+		},
+		{
+			name:        "empty key",
+			tags:        map[string]string{"": "value"},
+			errContains: `tags: key "" must be 1 to 128 characters`,
+		},
+		{
+			name:        "long key",
+			tags:        map[string]string{strings.Repeat("k", 129): "value"},
+			errContains: "must be 1 to 128 characters",
+		},
+		{
+			name:        "aws prefix",
+			tags:        map[string]string{"AWS:cloudformation:stack-name": "value"},
+			errContains: `tags: key "AWS:cloudformation:stack-name" uses the reserved aws: prefix`,
+		},
+		{
+			name:        "acorn managed tag",
+			tags:        map[string]string{"acorn.io/managed": "false"},
+			errContains: `tags: key "acorn.io/managed" is managed by Acorn and cannot be set`,
+		},
+		{
+			name:        "invalid key characters",
+			tags:        map[string]string{"team#1": "db"},
+			errContains: `tags: key "team#1" can only contain letters, numbers, spaces and _ . : / = + - @`,
+		},
+		{
+			name:        "long value",
+			tags:        map[string]string{"team": strings.Repeat("v", 257)},
+			errContains: `tags: value of "team" must be at most 256 characters`,
+		},
+		{
+			name:        "invalid value characters",
+			tags:        map[string]string{"team": "db & cache"},
+			errContains: `tags: value of "team" can only contain letters, numbers, spaces and _ . : / = + - @`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateTags(tt.tags); err != nil {
+				if tt.errContains == "" {
+					t.Errorf("unexpected error: %s", err)
+				} else if !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("expected error to contain %q, got %q", tt.errContains, err)
+				}
+			} else if tt.errContains != "" {
+				t.Errorf("expected error to contain %q, got nil", tt.errContains)
+			}
+		})
+	}
+}
+
+func TestTagPropagationProps(t *testing.T) {
+	tests := []struct {
+		name        string
+		props       TagPropagationProps
+		errContains string
+	}{
+		{
+			name: "no resource types",
+		},
+		{
+			name: "valid resource types",
+			props: TagPropagationProps{
+				TagsIncludeResourceTypes: []string{"AWS::RDS::DBCluster", "AWS::RDS::DBInstance"},
+				TagsExcludeResourceTypes: []string{"AWS::KMS::Key"},
+			},
+		},
+		{
+			name:        "invalid include resource type",
+			props:       TagPropagationProps{TagsIncludeResourceTypes: []string{"AWS::S3"}},
+			errContains: `tagsIncludeResourceTypes: "AWS::S3" is not a valid resource type`,
+		},
+		{
+			name:        "invalid exclude resource type",
+			props:       TagPropagationProps{TagsExcludeResourceTypes: []string{"dynamodb table"}},
+			errContains: `tagsExcludeResourceTypes: "dynamodb table" is not a valid resource type`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.props.Validate(); err != nil {
+				if tt.errContains == "" {
+					t.Errorf("unexpected error: %s", err)
+				} else if !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("expected error to contain %q, got %q", tt.errContains, err)
+				}
+			} else if tt.errContains != "" {
+				t.Errorf("expected error to contain %q, got nil", tt.errContains)
+			}
+
+			// Unset resource types are left out of the tag props
+			tagProps := tt.props.TagProps()
+			if (tagProps.IncludeResourceTypes == nil) != (len(tt.props.TagsIncludeResourceTypes) == 0) {
+				t.Errorf("expected include resource types %v, got %v", tt.props.TagsIncludeResourceTypes, tagProps.IncludeResourceTypes)
+			}
+			if (tagProps.ExcludeResourceTypes == nil) != (len(tt.props.TagsExcludeResourceTypes) == 0) {
+				t.Errorf("expected exclude resource types %v, got %v", tt.props.TagsExcludeResourceTypes, tagProps.ExcludeResourceTypes)
+			}
+		})
+	}
+}
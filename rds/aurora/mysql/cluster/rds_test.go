@@ -0,0 +1,35 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/acorn-io/aws/rds"
+	"github.com/acorn-io/services/aws/libs/common/stacktest"
+)
+
+func TestNewVariantStackGolden(t *testing.T) {
+	tests := []struct {
+		name      string
+		overrides string
+	}{
+		{
+			name: "defaults",
+		},
+		{
+			name:      "features",
+			overrides: `{"username": "app", "clusterParameters": {"max_connections": "1000"}, "instanceParameters": {"long_query_time": "2"}, "createKey": true, "backupRetentionDays": 7, "cloudwatchLogsExports": ["error", "slowquery"], "cloudwatchLogsRetentionDays": 30, "allowedCidrs": ["10.1.0.0/16"], "tags": {"team": "platform"}}`,
+		},
+		{
+			name:      "tagged cluster",
+			overrides: `{"tags": {"team": "platform"}, "tagsIncludeResourceTypes": ["AWS::RDS::DBCluster", "AWS::RDS::DBInstance"]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			props := rds.NewStackProps(variant, stacktest.StackProps())
+			app := stacktest.NewAppWithConfig(t, stacktest.AcornfileConfig(t, "Acornfile", tt.overrides), props)
+			stacktest.AssertGolden(t, rds.NewVariantStack(app, props))
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/acorn-io/aws/rds"
+	"github.com/acorn-io/services/aws/libs/common/stacktest"
+)
+
+func TestNewVariantStackGolden(t *testing.T) {
+	tests := []struct {
+		name      string
+		overrides string
+	}{
+		{
+			name: "defaults",
+		},
+		{
+			name:      "migrated",
+			overrides: `{"migrateToServerlessV2": true}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			props := rds.NewStackProps(variant, stacktest.StackProps())
+			app := stacktest.NewAppWithConfig(t, stacktest.AcornfileConfig(t, "Acornfile", tt.overrides), props)
+			stacktest.AssertGolden(t, rds.NewVariantStack(app, props))
+		})
+	}
+}
@@ -1,11 +1,20 @@
 package rds
 
 import (
+	"errors"
 	"fmt"
+	"regexp"
+	"strings"
+	"time"
 
+	"github.com/acorn-io/services/aws/libs/common"
+	"github.com/acorn-io/services/aws/libs/common/naming"
 	"github.com/aws/aws-cdk-go/awscdk/v2"
 	"github.com/aws/aws-cdk-go/awscdk/v2/awsec2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsiam"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awskms"
 	"github.com/aws/aws-cdk-go/awscdk/v2/awsrds"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awssecretsmanager"
 	"github.com/aws/constructs-go/constructs/v10"
 	"github.com/aws/jsii-runtime-go"
 )
@@ -26,6 +35,41 @@ var (
 		"memoryOptimized":         awsec2.InstanceClass_R5,
 		"memoryOptimizedGraviton": awsec2.InstanceClass_R7G,
 	}
+	// SupportedLogTypes are the log types each Aurora engine can export to CloudWatch Logs
+	SupportedLogTypes = map[string][]string{
+		"aurora-mysql":      {"audit", "error", "general", "slowquery"},
+		"aurora-postgresql": {"postgresql"},
+	}
+	// DriftPolicies maps the driftPolicy values to the drift policies of the cdk-runner
+	DriftPolicies = map[string]string{
+		"fail":   "block",
+		"warn":   "warn",
+		"ignore": "off",
+	}
+	// LogRetentionDays are the retention periods accepted by CloudWatch Logs
+	LogRetentionDays = []int{1, 3, 5, 7, 14, 30, 60, 90, 120, 150, 180, 365, 400, 545, 731, 1096, 1827, 2192, 2557, 2922, 3288, 3653}
+
+	accountIDRegex         = regexp.MustCompile(`^\d{12}$`)
+	kmsKeyArnRegex         = regexp.MustCompile(`^arn:aws[a-z-]*:kms:[a-z0-9-]+:\d{12}:key/[a-zA-Z0-9-]+$`)
+	backupWindowRegex      = regexp.MustCompile(`^([01]\d|2[0-3]):[0-5]\d-([01]\d|2[0-3]):[0-5]\d$`)
+	globalClusterIDRegex   = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9-]{0,62}$`)
+	maintenanceWindowRegex = regexp.MustCompile(`^(mon|tue|wed|thu|fri|sat|sun):([01]\d|2[0-3]):[0-5]\d-(mon|tue|wed|thu|fri|sat|sun):([01]\d|2[0-3]):[0-5]\d$`)
+)
+
+const (
+	// MaxRotationDays is the longest rotation schedule allowed for the generated secrets
+	MaxRotationDays = 90
+	// MaxBackupRetentionDays is the longest automated backup retention RDS supports
+	MaxBackupRetentionDays = 35
+	// DefaultDriftPolicy is used when driftPolicy is not set, clusters are often tuned in the console
+	DefaultDriftPolicy = "warn"
+	// RotationFunctionPrefix starts the names of the secret rotation functions, the Acornfiles only let the
+	// apply job manage the functions with this prefix
+	RotationFunctionPrefix = "acorn-rds-rotation"
+	// LatestRestorableTime can be passed as restoreToTime to restore to the latest restorable time of the source cluster
+	LatestRestorableTime = "latest"
+
+	restoreToTimeLayout = "2006-01-02T15:04:05Z"
 )
 
 type RDSStackProps struct {
@@ -35,32 +79,216 @@ type RDSStackProps struct {
 	DatabaseName              string            `json:"dbName"`
 	DeletionProtection        bool              `json:"deletionProtection"`
 	EnablePerformanceInsights bool              `json:"enablePerformanceInsights"`
-	InstanceClass             string            `json:"instanceClass"`
-	InstanceSize              string            `json:"instanceSize"`
+	InstanceClass             string            `json:"instanceClass" jsonschema:"enum=burstable|burstableGraviton|standard|standardGraviton|memoryOptimized|memoryOptimizedGraviton"`
+	InstanceSize              string            `json:"instanceSize" jsonschema:"enum=small|medium|large|xlarge|2xlarge"`
 	Parameters                map[string]string `json:"parameters"`
+	ClusterParameters         map[string]string `json:"clusterParameters"`
+	InstanceParameters        map[string]string `json:"instanceParameters"`
 	RestoreSnapshotArn        string            `json:"restoreFromSnapshotArn"`
+	RestoreSourceClusterID    string            `json:"restoreSourceClusterIdentifier"`
+	RestoreToTime             string            `json:"restoreToTime"`
+	ShareSnapshotWithAccounts []string          `json:"shareFinalSnapshotWithAccounts" jsonschema:"pattern=^[0-9]{12}$"`
+	RotationDays              int               `json:"rotationDays" jsonschema:"minimum=0,maximum=90"`
+	KmsKeyArn                 string            `json:"kmsKeyArn"`
+	CreateKey                 bool              `json:"createKey"`
 	SkipSnapShotOnDelete      bool              `json:"skipSnapshotOnDelete"`
+	DriftPolicy               string            `json:"driftPolicy" jsonschema:"enum=fail|warn|ignore"`
 	Tags                      map[string]string `json:"tags"`
 	VpcID                     string
+	// Sources allowed to connect to the database, the private subnets of the VPC by default
+	common.IngressProps
+	// Resource types the user tags are applied to
+	common.TagPropagationProps
+	// Backup, maintenance and log settings shared by all engine variants
+	BackupRetentionDays         int      `json:"backupRetentionDays" jsonschema:"minimum=0,maximum=35"`
+	PreferredBackupWindow       string   `json:"preferredBackupWindow" jsonschema:"pattern=^([01][0-9]|2[0-3]):[0-5][0-9]-([01][0-9]|2[0-3]):[0-5][0-9]$"`
+	PreferredMaintenanceWindow  string   `json:"preferredMaintenanceWindow"`
+	CloudwatchLogsExports       []string `json:"cloudwatchLogsExports"`
+	CloudwatchLogsRetentionDays int      `json:"cloudwatchLogsRetentionDays"`
+	// Aurora global database settings, only supported by the provisioned and serverless v2 engine variants
+	CreateGlobalCluster     bool   `json:"createGlobalCluster"`
+	GlobalClusterIdentifier string `json:"globalClusterIdentifier"`
+	SourceRegion            string `json:"sourceRegion"`
+	SourceAdminSecretArn    string `json:"sourceAdminSecretArn"`
 	// Scaling units for serverless v1
-	AuroraCapacityUnitsMin   int `json:"auroraCapacityUnitsMin"`
-	AuroraCapacityUnitsMax   int `json:"auroraCapacityUnitsMax"`
-	AutoPauseDurationMinutes int `json:"autoPauseDurationMinutes"`
+	AuroraCapacityUnitsMin   int  `json:"auroraCapacityUnitsMin" jsonschema:"enum=1|2|4|8|16|32|64|128|256|384"`
+	AuroraCapacityUnitsMax   int  `json:"auroraCapacityUnitsMax" jsonschema:"enum=1|2|4|8|16|32|64|128|256|384"`
+	AutoPauseDurationMinutes int  `json:"autoPauseDurationMinutes"`
+	MigrateToServerlessV2    bool `json:"migrateToServerlessV2"`
 	// Scaling Units for serverless v2
 	AuroraCapacityUnitsV2Min float64 `json:"auroraCapacityUnitsV2Min"`
 	AuroraCapacityUnitsV2Max float64 `json:"auroraCapacityUnitsV2Max"`
+
+	// variant is the engine variant the props are validated against
+	variant *EngineVariant
+}
+
+// SetDefaults looks up the VPC ID from the environment when it is not configured
+func (props *RDSStackProps) SetDefaults() {
+	if props.VpcID == "" {
+		props.VpcID = common.GetVpcID()
+	}
+}
+
+// Validate validates the props, and the engine variant specific settings if the variant is known
+func (props *RDSStackProps) Validate() error {
+	if props.variant == nil {
+		return ValidateProps(props)
+	}
+	return errors.Join(ValidateProps(props), props.variant.Validate(props))
+}
+
+// GetTags returns the user tags applied to all resources
+func (props *RDSStackProps) GetTags() map[string]string {
+	return props.Tags
 }
 
 // ValidateProps validates the given props
 // returns an error if the props are invalid
 func ValidateProps(props *RDSStackProps) error {
+	var errs []error
 	if props.AdminUser == props.RegularUser {
-		return fmt.Errorf("the admin username (%s) must differ from the standard username (%s)", props.AdminUser, props.RegularUser)
+		errs = append(errs, fmt.Errorf("the admin username (%s) must differ from the standard username (%s)", props.AdminUser, props.RegularUser))
+	}
+
+	if props.RotationDays < 0 || props.RotationDays > MaxRotationDays {
+		errs = append(errs, fmt.Errorf("rotationDays must be between 0 and %d, 0 disables rotation. Passed in: %d", MaxRotationDays, props.RotationDays))
+	}
+
+	if props.BackupRetentionDays < 0 || props.BackupRetentionDays > MaxBackupRetentionDays {
+		errs = append(errs, fmt.Errorf("backupRetentionDays must be between 1 and %d, or 0 to use the AWS default. Passed in: %d", MaxBackupRetentionDays, props.BackupRetentionDays))
+	}
+
+	if props.PreferredBackupWindow != "" && !backupWindowRegex.MatchString(props.PreferredBackupWindow) {
+		errs = append(errs, fmt.Errorf("preferredBackupWindow must be in the format hh24:mi-hh24:mi (ex. 03:00-04:00). Passed in: %s", props.PreferredBackupWindow))
+	}
+
+	if props.PreferredMaintenanceWindow != "" && !maintenanceWindowRegex.MatchString(props.PreferredMaintenanceWindow) {
+		errs = append(errs, fmt.Errorf("preferredMaintenanceWindow must be in the format ddd:hh24:mi-ddd:hh24:mi (ex. sun:05:00-sun:06:00). Passed in: %s", props.PreferredMaintenanceWindow))
+	}
+
+	if props.CloudwatchLogsRetentionDays != 0 && !contains(LogRetentionDays, props.CloudwatchLogsRetentionDays) {
+		errs = append(errs, fmt.Errorf("cloudwatchLogsRetentionDays must be 0 or one of %v. Passed in: %d", LogRetentionDays, props.CloudwatchLogsRetentionDays))
+	}
+
+	if props.RestoreSourceClusterID != "" || props.RestoreToTime != "" {
+		if props.RestoreSourceClusterID == "" || props.RestoreToTime == "" {
+			errs = append(errs, fmt.Errorf("restoreSourceClusterIdentifier and restoreToTime must be set together"))
+		}
+		if props.RestoreSnapshotArn != "" {
+			errs = append(errs, fmt.Errorf("only one of restoreFromSnapshotArn or restoreSourceClusterIdentifier can be set"))
+		}
+	}
+
+	if props.RestoreToTime != "" && props.RestoreToTime != LatestRestorableTime {
+		if _, err := time.Parse(restoreToTimeLayout, props.RestoreToTime); err != nil {
+			errs = append(errs, fmt.Errorf("restoreToTime must be %q or a UTC timestamp (ex. 2023-10-01T15:04:05Z). Passed in: %s", LatestRestorableTime, props.RestoreToTime))
+		}
+	}
+
+	if len(props.ShareSnapshotWithAccounts) > 0 && props.SkipSnapShotOnDelete {
+		errs = append(errs, fmt.Errorf("shareFinalSnapshotWithAccounts cannot be used with skipSnapshotOnDelete"))
+	}
+
+	if len(props.ShareSnapshotWithAccounts) > 0 && props.KmsKeyArn == "" && !props.CreateKey {
+		errs = append(errs, fmt.Errorf("shareFinalSnapshotWithAccounts requires kmsKeyArn or createKey, snapshots encrypted with the AWS managed key cannot be shared"))
+	}
+
+	for _, account := range props.ShareSnapshotWithAccounts {
+		if !accountIDRegex.MatchString(account) {
+			errs = append(errs, fmt.Errorf("shareFinalSnapshotWithAccounts must contain 12 digit AWS account IDs. Passed in: %s", account))
+		}
+	}
+
+	if props.KmsKeyArn != "" && props.CreateKey {
+		errs = append(errs, fmt.Errorf("only one of kmsKeyArn or createKey can be set"))
+	}
+
+	if props.KmsKeyArn != "" && !kmsKeyArnRegex.MatchString(props.KmsKeyArn) {
+		errs = append(errs, fmt.Errorf("kmsKeyArn must be the ARN of a KMS key (ex. arn:aws:kms:us-east-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab). Passed in: %s", props.KmsKeyArn))
+	}
+
+	if _, ok := DriftPolicies[props.DriftPolicy]; props.DriftPolicy != "" && !ok {
+		errs = append(errs, fmt.Errorf("driftPolicy must be one of fail, warn or ignore. Passed in: %s", props.DriftPolicy))
+	}
+
+	if err := props.IngressProps.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := props.TagPropagationProps.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+
+	errs = append(errs, validateGlobalProps(props)...)
+
+	return errors.Join(errs...)
+}
+
+func validateGlobalProps(props *RDSStackProps) []error {
+	var errs []error
+	if props.GlobalClusterIdentifier != "" && !globalClusterIDRegex.MatchString(props.GlobalClusterIdentifier) {
+		errs = append(errs, fmt.Errorf("globalClusterIdentifier must start with a letter and contain at most 63 letters, digits or hyphens. Passed in: %s", props.GlobalClusterIdentifier))
+	}
+
+	if props.SourceAdminSecretArn != "" && props.SourceRegion == "" {
+		errs = append(errs, fmt.Errorf("sourceAdminSecretArn can only be used with sourceRegion"))
 	}
 
-	return nil
+	if !props.IsGlobalSecondary() {
+		if props.GlobalClusterIdentifier != "" && !props.CreateGlobalCluster {
+			errs = append(errs, fmt.Errorf("globalClusterIdentifier must be used with createGlobalCluster or sourceRegion"))
+		}
+		return errs
+	}
+
+	if props.GlobalClusterIdentifier == "" {
+		errs = append(errs, fmt.Errorf("globalClusterIdentifier must be set to join a global cluster with sourceRegion"))
+	}
+	if props.CreateGlobalCluster {
+		errs = append(errs, fmt.Errorf("only one of createGlobalCluster or sourceRegion can be set"))
+	}
+	if props.Env != nil && props.Env.Region != nil && *props.Env.Region == props.SourceRegion {
+		errs = append(errs, fmt.Errorf("sourceRegion must differ from the region of this cluster. Passed in: %s", props.SourceRegion))
+	}
+	// The data, users and credentials of a secondary cluster are all replicated from the primary cluster
+	if props.RegularUser != "" {
+		errs = append(errs, fmt.Errorf("username cannot be used with sourceRegion, users are replicated from the primary cluster"))
+	}
+	if props.RestoreSnapshotArn != "" || props.RestoreSourceClusterID != "" {
+		errs = append(errs, fmt.Errorf("restoreFromSnapshotArn and restoreSourceClusterIdentifier cannot be used with sourceRegion"))
+	}
+	if props.RotationDays > 0 {
+		errs = append(errs, fmt.Errorf("rotationDays cannot be used with sourceRegion, the credentials are managed by the primary cluster"))
+	}
+	if len(props.ShareSnapshotWithAccounts) > 0 {
+		errs = append(errs, fmt.Errorf("shareFinalSnapshotWithAccounts cannot be used with sourceRegion, secondary clusters do not take a final snapshot"))
+	}
+	return errs
 }
 
+// RunnerDriftPolicy returns the cdk-runner drift policy of the driftPolicy, warn when it is not set
+func (props *RDSStackProps) RunnerDriftPolicy() string {
+	if props.DriftPolicy == "" {
+		return DriftPolicies[DefaultDriftPolicy]
+	}
+	return DriftPolicies[props.DriftPolicy]
+}
+
+// IsGlobalSecondary returns true if the stack joins an existing global cluster as a secondary region
+func (props *RDSStackProps) IsGlobalSecondary() bool {
+	return props.SourceRegion != ""
+}
+
+// AllowReplacementMetadataKey is the template metadata key listing the restore properties of a resource. The
+// cdk-runner applies a change set that replaces the resource when it adds or changes one of them, restoring an
+// existing cluster replaces it.
+const AllowReplacementMetadataKey = "acorn.io/allow-replacement"
+
+// DriftPolicyMetadataKey is the template metadata key that sets what the cdk-runner does when resources of the
+// stack drifted before an update: off, report, warn or block
+const DriftPolicyMetadataKey = "acorn.io/drift-policy"
+
+// SnapshotAspect creates the cluster by restoring a snapshot
 type SnapshotAspect struct {
 	SnapshotIdentifier string
 }
@@ -68,6 +296,7 @@ type SnapshotAspect struct {
 func (sa *SnapshotAspect) Visit(node constructs.IConstruct) {
 	if n, ok := node.(awsrds.CfnDBCluster); ok {
 		n.AddPropertyOverride(jsii.String("SnapshotIdentifier"), jsii.String(sa.SnapshotIdentifier))
+		n.AddMetadata(jsii.String(AllowReplacementMetadataKey), []string{"SnapshotIdentifier"})
 	}
 }
 
@@ -77,22 +306,103 @@ func NewSnapshotAspect(snapshotIdentifier string) *SnapshotAspect {
 	}
 }
 
-func NewParameterGroup(scope constructs.Construct, name *string, props *RDSStackProps, engine awsrds.IClusterEngine) awsrds.ParameterGroup {
-	parameterGroup := awsrds.NewParameterGroup(scope, name, &awsrds.ParameterGroupProps{
-		Engine:      engine,
-		Description: jsii.String("Acorn created RDS Parameter Group"),
-		Parameters:  mapStringToMapStringPtr(props.Parameters),
-	})
+// PointInTimeRestoreAspect creates the cluster by restoring the source cluster to a point in time
+type PointInTimeRestoreAspect struct {
+	SourceClusterIdentifier string
+	RestoreToTime           string
+}
+
+func (pa *PointInTimeRestoreAspect) Visit(node constructs.IConstruct) {
+	if n, ok := node.(awsrds.CfnDBCluster); ok {
+		n.AddPropertyOverride(jsii.String("SourceDBClusterIdentifier"), jsii.String(pa.SourceClusterIdentifier))
+		n.AddMetadata(jsii.String(AllowReplacementMetadataKey), []string{"SourceDBClusterIdentifier", "RestoreToTime", "UseLatestRestorableTime"})
+		if pa.RestoreToTime == LatestRestorableTime {
+			n.AddPropertyOverride(jsii.String("UseLatestRestorableTime"), jsii.Bool(true))
+		} else {
+			n.AddPropertyOverride(jsii.String("RestoreToTime"), jsii.String(pa.RestoreToTime))
+		}
+	}
+}
+
+func NewPointInTimeRestoreAspect(sourceClusterIdentifier, restoreToTime string) *PointInTimeRestoreAspect {
+	return &PointInTimeRestoreAspect{
+		SourceClusterIdentifier: sourceClusterIdentifier,
+		RestoreToTime:           restoreToTime,
+	}
+}
+
+// MaintenanceAspect applies the backup, maintenance window and log export settings to every Aurora cluster in the
+// scope, so provisioned and serverless clusters are configured the same way.
+type MaintenanceAspect struct {
+	BackupRetentionDays        int
+	PreferredBackupWindow      string
+	PreferredMaintenanceWindow string
+	CloudwatchLogsExports      []string
+}
+
+func (ma *MaintenanceAspect) Visit(node constructs.IConstruct) {
+	n, ok := node.(awsrds.CfnDBCluster)
+	if !ok {
+		return
+	}
+
+	if ma.BackupRetentionDays > 0 {
+		n.SetBackupRetentionPeriod(jsii.Number(ma.BackupRetentionDays))
+	}
+	if ma.PreferredBackupWindow != "" {
+		n.SetPreferredBackupWindow(jsii.String(ma.PreferredBackupWindow))
+	}
+	if ma.PreferredMaintenanceWindow != "" {
+		n.SetPreferredMaintenanceWindow(jsii.String(ma.PreferredMaintenanceWindow))
+	}
+
+	if len(ma.CloudwatchLogsExports) == 0 {
+		return
+	}
+
+	// Serverless v1 clusters publish their logs based on the parameter group and reject explicit exports
+	if n.EngineMode() != nil && *n.EngineMode() == "serverless" {
+		awscdk.Annotations_Of(node).AddError(jsii.String("cloudwatchLogsExports is not supported by Aurora Serverless v1 clusters"))
+		return
+	}
 
-	return parameterGroup
+	engine := jsii.String("")
+	if n.Engine() != nil {
+		engine = n.Engine()
+	}
+	for _, logType := range ma.CloudwatchLogsExports {
+		if !contains(SupportedLogTypes[*engine], logType) {
+			awscdk.Annotations_Of(node).AddError(jsii.String(fmt.Sprintf("unsupported log type %q for engine %s, supported log types: %v", logType, *engine, SupportedLogTypes[*engine])))
+			return
+		}
+	}
+	n.SetEnableCloudwatchLogsExports(stringsToPtrs(ma.CloudwatchLogsExports))
 }
 
-func mapStringToMapStringPtr(from map[string]string) *map[string]*string {
-	to := &map[string]*string{}
-	for k, v := range from {
-		(*to)[k] = jsii.String(v)
+func NewMaintenanceAspect(props *RDSStackProps) *MaintenanceAspect {
+	return &MaintenanceAspect{
+		BackupRetentionDays:        props.BackupRetentionDays,
+		PreferredBackupWindow:      props.PreferredBackupWindow,
+		PreferredMaintenanceWindow: props.PreferredMaintenanceWindow,
+		CloudwatchLogsExports:      props.CloudwatchLogsExports,
 	}
-	return to
+}
+
+func contains[T comparable](from []T, v T) bool {
+	for _, f := range from {
+		if f == v {
+			return true
+		}
+	}
+	return false
+}
+
+func stringsToPtrs(from []string) *[]*string {
+	to := make([]*string, 0, len(from))
+	for _, v := range from {
+		to = append(to, jsii.String(v))
+	}
+	return &to
 }
 
 func ValidInstanceParameters(instanceClass string, instanceSize string) bool {
@@ -112,6 +422,48 @@ func GetRemovalPolicy(props *RDSStackProps) awscdk.RemovalPolicy {
 	return awscdk.RemovalPolicy_SNAPSHOT
 }
 
+// GetStorageEncryptionKey returns the customer managed key used to encrypt the cluster storage, either imported
+// from kmsKeyArn or created in the stack when createKey is set. Returns nil if the AWS managed key should be used.
+func GetStorageEncryptionKey(scope constructs.Construct, name *string, props *RDSStackProps) awskms.IKey {
+	if props.KmsKeyArn != "" {
+		return awskms.Key_FromKeyArn(scope, name, jsii.String(props.KmsKeyArn))
+	}
+
+	if !props.CreateKey {
+		return nil
+	}
+
+	// Snapshots taken on delete are encrypted with this key, so it has to outlive the cluster unless they are skipped.
+	removalPolicy := awscdk.RemovalPolicy_RETAIN
+	if props.SkipSnapShotOnDelete {
+		removalPolicy = awscdk.RemovalPolicy_DESTROY
+	}
+
+	key := awskms.NewKey(scope, name, &awskms.KeyProps{
+		Description:       jsii.String("Acorn created RDS storage encryption key"),
+		EnableKeyRotation: jsii.Bool(true),
+		RemovalPolicy:     removalPolicy,
+	})
+
+	// The accounts the final snapshot is shared with need the key to copy or restore it
+	for _, account := range props.ShareSnapshotWithAccounts {
+		key.AddToResourcePolicy(awsiam.NewPolicyStatement(&awsiam.PolicyStatementProps{
+			Principals: &[]awsiam.IPrincipal{awsiam.NewAccountPrincipal(jsii.String(account))},
+			Actions:    jsii.Strings("kms:Decrypt", "kms:CreateGrant", "kms:DescribeKey"),
+			Resources:  jsii.Strings("*"),
+		}), nil)
+	}
+
+	return key
+}
+
+// LookupVpc looks up the VPC the Acorn runs in
+func LookupVpc(scope constructs.Construct, name *string, vpcID string) awsec2.IVpc {
+	return awsec2.Vpc_FromLookup(scope, name, &awsec2.VpcLookupOptions{
+		VpcId: jsii.String(vpcID),
+	})
+}
+
 func GetPrivateSubnetGroup(scope constructs.Construct, name *string, vpc awsec2.IVpc) awsrds.SubnetGroup {
 	subnetGroup := awsrds.NewSubnetGroup(scope, name, &awsrds.SubnetGroupProps{
 		Description: jsii.String("Acorn created RDS Subnets"),
@@ -123,3 +475,92 @@ func GetPrivateSubnetGroup(scope constructs.Construct, name *string, vpc awsec2.
 
 	return subnetGroup
 }
+
+// RotatableCluster is implemented by both provisioned and serverless Aurora clusters
+type RotatableCluster interface {
+	awssecretsmanager.ISecretAttachmentTarget
+	Secret() awssecretsmanager.ISecret
+	AddRotationSingleUser(options *awsrds.RotationSingleUserOptions) awssecretsmanager.SecretRotation
+	AddRotationMultiUser(id *string, options *awsrds.RotationMultiUserOptions) awssecretsmanager.SecretRotation
+}
+
+// AddSecretRotation attaches the single user rotation application to the admin secret of the cluster. If a
+// regular user is configured, a dedicated secret is created for that user and the multi user rotation
+// application is attached to it, using the admin secret as the master secret.
+// The rotation functions run in the private subnets with the given security group so they can reach the cluster.
+// Returns the user secret, or nil if rotation is disabled or no regular user is configured.
+func AddSecretRotation(scope constructs.Construct, cluster RotatableCluster, props *RDSStackProps, sg awsec2.ISecurityGroup) awssecretsmanager.ISecret {
+	if props.RotationDays == 0 {
+		return nil
+	}
+
+	subnets := &awsec2.SubnetSelection{
+		SubnetType: awsec2.SubnetType_PRIVATE_WITH_EGRESS,
+	}
+	// Rotating on every update would change the credentials out from under the Acorn outputs,
+	// so only rotate on the schedule.
+	adminRotation := cluster.AddRotationSingleUser(&awsrds.RotationSingleUserOptions{
+		AutomaticallyAfter:        awscdk.Duration_Days(jsii.Number(props.RotationDays)),
+		RotateImmediatelyOnUpdate: jsii.Bool(false),
+		SecurityGroup:             sg,
+		VpcSubnets:                subnets,
+	})
+	setRotationFunctionName(adminRotation, "admin")
+
+	if props.RegularUser == "" {
+		return nil
+	}
+
+	userSecret := awsrds.NewDatabaseSecret(scope, jsii.String("UserSecret"), &awsrds.DatabaseSecretProps{
+		Username:     jsii.String(props.RegularUser),
+		Dbname:       jsii.String(props.DatabaseName),
+		MasterSecret: cluster.Secret(),
+	}).Attach(cluster)
+
+	userRotation := cluster.AddRotationMultiUser(jsii.String("UserRotation"), &awsrds.RotationMultiUserOptions{
+		Secret:                    userSecret,
+		AutomaticallyAfter:        awscdk.Duration_Days(jsii.Number(props.RotationDays)),
+		RotateImmediatelyOnUpdate: jsii.Bool(false),
+		SecurityGroup:             sg,
+		VpcSubnets:                subnets,
+	})
+	setRotationFunctionName(userRotation, "user")
+
+	return userSecret
+}
+
+// RotationFunctionName returns the name of the rotation function of the secret, unique to the Acorn
+func RotationFunctionName(externalID, secret string) string {
+	return naming.LambdaFunction.Name(RotationFunctionPrefix, externalID, secret)
+}
+
+// setRotationFunctionName replaces the function name CDK generates from the construct path, which is the same
+// for every Acorn and would clash between two Acorns in the same region
+func setRotationFunctionName(rotation awssecretsmanager.SecretRotation, secret string) {
+	var application awscdk.CfnResource
+	jsii.UnsafeCast(rotation.Node().DefaultChild(), &application)
+	application.AddPropertyOverride(jsii.String("Parameters.functionName"), RotationFunctionName(naming.AcornFromEnv().ExternalID, secret))
+}
+
+// AddConfigOutputs outputs the settings the service script and the hooks act on after the deploy, so they read the
+// config the stack was synthesized from rather than the config file, which may have been passed elsewhere
+func AddConfigOutputs(stack awscdk.Stack, props *RDSStackProps) {
+	if props.CloudwatchLogsRetentionDays != 0 && len(props.CloudwatchLogsExports) > 0 {
+		awscdk.NewCfnOutput(stack, jsii.String("logsexports"), &awscdk.CfnOutputProps{
+			Value: jsii.String(strings.Join(props.CloudwatchLogsExports, ",")),
+		})
+		awscdk.NewCfnOutput(stack, jsii.String("logsretentiondays"), &awscdk.CfnOutputProps{
+			Value: jsii.String(fmt.Sprint(props.CloudwatchLogsRetentionDays)),
+		})
+	}
+	if props.RotationDays > 0 {
+		awscdk.NewCfnOutput(stack, jsii.String("rotationdays"), &awscdk.CfnOutputProps{
+			Value: jsii.String(fmt.Sprint(props.RotationDays)),
+		})
+	}
+	if len(props.ShareSnapshotWithAccounts) > 0 {
+		awscdk.NewCfnOutput(stack, jsii.String("sharesnapshotaccounts"), &awscdk.CfnOutputProps{
+			Value: jsii.String(strings.Join(props.ShareSnapshotWithAccounts, ",")),
+		})
+	}
+}
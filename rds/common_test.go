@@ -0,0 +1,348 @@
+package rds
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/acorn-io/services/aws/libs/common"
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/jsii-runtime-go"
+)
+
+func TestValidateProps(t *testing.T) {
+	tests := []struct {
+		name        string
+		props       RDSStackProps
+		errContains string
+	}{
+		{
+			name: "valid",
+			props: RDSStackProps{
+				AdminUser:   "admin",
+				RegularUser: "user",
+			},
+		},
+		{
+			name: "matching usernames",
+			props: RDSStackProps{
+				AdminUser:   "admin",
+				RegularUser: "admin",
+			},
+			errContains: "must differ from the standard username",
+		},
+		{
+			name: "valid rotationDays",
+			props: RDSStackProps{
+				AdminUser:    "admin",
+				RotationDays: 90,
+			},
+		},
+		{
+			name: "rotationDays too long",
+			props: RDSStackProps{
+				AdminUser:    "admin",
+				RotationDays: 91,
+			},
+			errContains: "rotationDays must be between 0 and 90",
+		},
+		{
+			name: "negative rotationDays",
+			props: RDSStackProps{
+				AdminUser:    "admin",
+				RotationDays: -1,
+			},
+			errContains: "rotationDays must be between 0 and 90",
+		},
+		{
+			name: "valid backup and maintenance settings",
+			props: RDSStackProps{
+				AdminUser:                   "admin",
+				BackupRetentionDays:         35,
+				PreferredBackupWindow:       "03:00-04:00",
+				PreferredMaintenanceWindow:  "sun:05:00-sun:06:00",
+				CloudwatchLogsRetentionDays: 30,
+			},
+		},
+		{
+			name: "backupRetentionDays too long",
+			props: RDSStackProps{
+				AdminUser:           "admin",
+				BackupRetentionDays: 36,
+			},
+			errContains: "backupRetentionDays must be between 1 and 35",
+		},
+		{
+			name: "invalid preferredBackupWindow",
+			props: RDSStackProps{
+				AdminUser:             "admin",
+				PreferredBackupWindow: "3am-4am",
+			},
+			errContains: "preferredBackupWindow must be in the format hh24:mi-hh24:mi",
+		},
+		{
+			name: "invalid preferredMaintenanceWindow",
+			props: RDSStackProps{
+				AdminUser:                  "admin",
+				PreferredMaintenanceWindow: "sunday:05:00-sunday:06:00",
+			},
+			errContains: "preferredMaintenanceWindow must be in the format ddd:hh24:mi-ddd:hh24:mi",
+		},
+		{
+			name: "invalid cloudwatchLogsRetentionDays",
+			props: RDSStackProps{
+				AdminUser:                   "admin",
+				CloudwatchLogsRetentionDays: 2,
+			},
+			errContains: "cloudwatchLogsRetentionDays must be 0 or one of",
+		},
+		{
+			name: "valid kmsKeyArn",
+			props: RDSStackProps{
+				AdminUser: "admin",
+				KmsKeyArn: "arn:aws:kms:us-east-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab",
+			},
+		},
+		{
+			name: "invalid kmsKeyArn",
+			props: RDSStackProps{
+				AdminUser: "admin",
+				KmsKeyArn: "arn:aws:kms:us-east-1:123456789012:alias/my-key",
+			},
+			errContains: "kmsKeyArn must be the ARN of a KMS key",
+		},
+		{
+			name: "kmsKeyArn and createKey",
+			props: RDSStackProps{
+				AdminUser: "admin",
+				KmsKeyArn: "arn:aws:kms:us-east-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab",
+				CreateKey: true,
+			},
+			errContains: "only one of kmsKeyArn or createKey can be set",
+		},
+		{
+			name: "valid point in time restore",
+			props: RDSStackProps{
+				AdminUser:              "admin",
+				RestoreSourceClusterID: "source-cluster",
+				RestoreToTime:          "2023-10-01T15:04:05Z",
+			},
+		},
+		{
+			name: "point in time restore to latest",
+			props: RDSStackProps{
+				AdminUser:              "admin",
+				RestoreSourceClusterID: "source-cluster",
+				RestoreToTime:          LatestRestorableTime,
+			},
+		},
+		{
+			name: "restoreToTime without source cluster",
+			props: RDSStackProps{
+				AdminUser:     "admin",
+				RestoreToTime: LatestRestorableTime,
+			},
+			errContains: "restoreSourceClusterIdentifier and restoreToTime must be set together",
+		},
+		{
+			name: "invalid restoreToTime",
+			props: RDSStackProps{
+				AdminUser:              "admin",
+				RestoreSourceClusterID: "source-cluster",
+				RestoreToTime:          "2023-10-01T15:04:05+02:00",
+			},
+			errContains: "restoreToTime must be",
+		},
+		{
+			name: "point in time restore and snapshot",
+			props: RDSStackProps{
+				AdminUser:              "admin",
+				RestoreSnapshotArn:     "arn:aws:rds:us-east-1:123456789012:cluster-snapshot:snapshot",
+				RestoreSourceClusterID: "source-cluster",
+				RestoreToTime:          LatestRestorableTime,
+			},
+			errContains: "only one of restoreFromSnapshotArn or restoreSourceClusterIdentifier can be set",
+		},
+		{
+			name: "invalid shareFinalSnapshotWithAccounts",
+			props: RDSStackProps{
+				AdminUser:                 "admin",
+				ShareSnapshotWithAccounts: []string{"123456789012", "1234"},
+			},
+			errContains: "shareFinalSnapshotWithAccounts must contain 12 digit AWS account IDs. Passed in: 1234",
+		},
+		{
+			name: "shareFinalSnapshotWithAccounts without a snapshot",
+			props: RDSStackProps{
+				AdminUser:                 "admin",
+				ShareSnapshotWithAccounts: []string{"123456789012"},
+				SkipSnapShotOnDelete:      true,
+			},
+			errContains: "shareFinalSnapshotWithAccounts cannot be used with skipSnapshotOnDelete",
+		},
+		{
+			name: "shareFinalSnapshotWithAccounts without a customer managed key",
+			props: RDSStackProps{
+				AdminUser:                 "admin",
+				ShareSnapshotWithAccounts: []string{"123456789012"},
+			},
+			errContains: "shareFinalSnapshotWithAccounts requires kmsKeyArn or createKey",
+		},
+		{
+			name: "valid shareFinalSnapshotWithAccounts",
+			props: RDSStackProps{
+				AdminUser:                 "admin",
+				ShareSnapshotWithAccounts: []string{"123456789012"},
+				CreateKey:                 true,
+			},
+		},
+		{
+			name: "valid global cluster primary",
+			props: RDSStackProps{
+				AdminUser:               "admin",
+				CreateGlobalCluster:     true,
+				GlobalClusterIdentifier: "global-db",
+			},
+		},
+		{
+			name: "valid global cluster secondary",
+			props: RDSStackProps{
+				StackProps:              awscdk.StackProps{Env: &awscdk.Environment{Region: jsii.String("us-west-2")}},
+				AdminUser:               "admin",
+				GlobalClusterIdentifier: "global-db",
+				SourceRegion:            "us-east-1",
+				SourceAdminSecretArn:    "arn:aws:secretsmanager:us-east-1:123456789012:secret:admin-AbCdEf",
+			},
+		},
+		{
+			name: "invalid globalClusterIdentifier",
+			props: RDSStackProps{
+				AdminUser:               "admin",
+				CreateGlobalCluster:     true,
+				GlobalClusterIdentifier: "1-global-db",
+			},
+			errContains: "globalClusterIdentifier must start with a letter",
+		},
+		{
+			name: "globalClusterIdentifier without a mode",
+			props: RDSStackProps{
+				AdminUser:               "admin",
+				GlobalClusterIdentifier: "global-db",
+			},
+			errContains: "globalClusterIdentifier must be used with createGlobalCluster or sourceRegion",
+		},
+		{
+			name: "sourceRegion without globalClusterIdentifier",
+			props: RDSStackProps{
+				AdminUser:    "admin",
+				SourceRegion: "us-east-1",
+			},
+			errContains: "globalClusterIdentifier must be set to join a global cluster with sourceRegion",
+		},
+		{
+			name: "sourceRegion and createGlobalCluster",
+			props: RDSStackProps{
+				AdminUser:               "admin",
+				CreateGlobalCluster:     true,
+				GlobalClusterIdentifier: "global-db",
+				SourceRegion:            "us-east-1",
+			},
+			errContains: "only one of createGlobalCluster or sourceRegion can be set",
+		},
+		{
+			name: "sourceRegion matches the stack region",
+			props: RDSStackProps{
+				StackProps:              awscdk.StackProps{Env: &awscdk.Environment{Region: jsii.String("us-east-1")}},
+				AdminUser:               "admin",
+				GlobalClusterIdentifier: "global-db",
+				SourceRegion:            "us-east-1",
+			},
+			errContains: "sourceRegion must differ from the region of this cluster",
+		},
+		{
+			name: "secondary with username",
+			props: RDSStackProps{
+				AdminUser:               "admin",
+				RegularUser:             "user",
+				GlobalClusterIdentifier: "global-db",
+				SourceRegion:            "us-east-1",
+			},
+			errContains: "username cannot be used with sourceRegion",
+		},
+		{
+			name: "secondary with rotation",
+			props: RDSStackProps{
+				AdminUser:               "admin",
+				RotationDays:            30,
+				GlobalClusterIdentifier: "global-db",
+				SourceRegion:            "us-east-1",
+			},
+			errContains: "rotationDays cannot be used with sourceRegion",
+		},
+		{
+			name: "sourceAdminSecretArn without sourceRegion",
+			props: RDSStackProps{
+				AdminUser:            "admin",
+				SourceAdminSecretArn: "arn:aws:secretsmanager:us-east-1:123456789012:secret:admin-AbCdEf",
+			},
+			errContains: "sourceAdminSecretArn can only be used with sourceRegion",
+		},
+		{
+			name: "valid driftPolicy",
+			props: RDSStackProps{
+				AdminUser:   "admin",
+				DriftPolicy: "fail",
+			},
+		},
+		{
+			name: "invalid driftPolicy",
+			props: RDSStackProps{
+				AdminUser:   "admin",
+				DriftPolicy: "block",
+			},
+			errContains: "driftPolicy must be one of fail, warn or ignore. Passed in: block",
+		},
+		{
+			name: "invalid ingress security group",
+			props: RDSStackProps{
+				AdminUser:    "admin",
+				IngressProps: common.IngressProps{AllowedSecurityGroupIDs: []string{"app"}},
+			},
+			errContains: `allowedSecurityGroupIds: "app" is not a valid security group ID`,
+		},
+		{
+			name: "invalid tag resource type",
+			props: RDSStackProps{
+				AdminUser:           "admin",
+				TagPropagationProps: common.TagPropagationProps{TagsExcludeResourceTypes: []string{"KMS key"}},
+			},
+			errContains: `tagsExcludeResourceTypes: "KMS key" is not a valid resource type`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateProps(&tt.props); err != nil {
+				if tt.errContains == "" {
+					t.Errorf("unexpected error: %s", err)
+				} else if !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("expected error to contain %q, got %q", tt.errContains, err)
+				}
+			} else if tt.errContains != "" {
+				t.Errorf("expected error to contain %q, got nil", tt.errContains)
+			}
+		})
+	}
+}
+
+func TestAcornfileArgs(t *testing.T) {
+	for _, acornfile := range []string{
+		"aurora/mysql/cluster/Acornfile",
+		"aurora/mysql/serverless-v1/Acornfile",
+		"aurora/mysql/serverless-v2/Acornfile",
+		"aurora/postgres/cluster/Acornfile",
+	} {
+		if err := common.ValidateAcornfileArgs(&RDSStackProps{}, acornfile); err != nil {
+			t.Error(err)
+		}
+	}
+}
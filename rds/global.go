@@ -0,0 +1,140 @@
+package rds
+
+import (
+	"fmt"
+
+	"github.com/acorn-io/services/aws/libs/common"
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsec2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsrds"
+	"github.com/aws/constructs-go/constructs/v10"
+	"github.com/aws/jsii-runtime-go"
+)
+
+// NewGlobalCluster creates an Aurora global cluster with the given cluster as its primary, and outputs its
+// identifier so secondary region stacks can join it.
+func NewGlobalCluster(scope constructs.Construct, name *string, cluster Cluster, props *RDSStackProps) awsrds.CfnGlobalCluster {
+	var identifier *string
+	if props.GlobalClusterIdentifier != "" {
+		identifier = jsii.String(props.GlobalClusterIdentifier)
+	}
+
+	globalCluster := awsrds.NewCfnGlobalCluster(scope, name, &awsrds.CfnGlobalClusterProps{
+		GlobalClusterIdentifier:   identifier,
+		SourceDbClusterIdentifier: cluster.ClusterIdentifier(),
+		DeletionProtection:        jsii.Bool(props.DeletionProtection),
+	})
+
+	awscdk.NewCfnOutput(scope, jsii.String("globalclusterid"), &awscdk.CfnOutputProps{
+		Value: globalCluster.Ref(),
+	})
+
+	return globalCluster
+}
+
+// NewGlobalSecondaryStack creates a headless cluster that joins an existing Aurora global cluster as a secondary
+// region, with a single reader instance. The data, users and credentials are replicated from the primary cluster,
+// so the admin credentials are passed through from sourceAdminSecretArn.
+func NewGlobalSecondaryStack(scope constructs.Construct, props *RDSStackProps, variant *EngineVariant) awscdk.Stack {
+	var sprops awscdk.StackProps
+	if props != nil {
+		sprops = props.StackProps
+	}
+
+	stack := awscdk.NewStack(scope, jsii.String("Stack"), &sprops)
+
+	vpc := LookupVpc(stack, jsii.String("VPC"), props.VpcID)
+
+	subnetGroup := GetPrivateSubnetGroup(stack, jsii.String("SubnetGroup"), vpc)
+
+	sg := common.GetIngressSecurityGroup(stack, jsii.String("SG"), jsii.String("Acorn generated RDS security group."), vpc, variant.Port, &props.IngressProps)
+
+	// Each region of an encrypted global cluster is encrypted with a key from that region
+	key := GetStorageEncryptionKey(stack, jsii.String("Key"), props)
+	var keyArn *string
+	if key != nil {
+		keyArn = key.KeyArn()
+	}
+
+	var parameterGroupName *string
+	if len(props.clusterParameters()) > 0 {
+		parameterGroup := NewParameterGroup(stack, jsii.String("ParameterGroup"), props, variant.Engine)
+		parameterGroupName = parameterGroup.BindToCluster(&awsrds.ParameterGroupClusterBindOptions{}).ParameterGroupName
+	}
+
+	var instanceParameterGroupName *string
+	if len(props.InstanceParameters) > 0 {
+		instanceParameterGroup := NewInstanceParameterGroup(stack, jsii.String("InstanceParameterGroup"), props, variant.Engine)
+		instanceParameterGroupName = instanceParameterGroup.BindToInstance(&awsrds.ParameterGroupInstanceBindOptions{}).ParameterGroupName
+	}
+
+	cluster := awsrds.NewCfnDBCluster(stack, jsii.String("Cluster"), &awsrds.CfnDBClusterProps{
+		Engine:                      variant.Engine.EngineType(),
+		EngineVersion:               variant.Engine.EngineVersion().FullVersion,
+		GlobalClusterIdentifier:     jsii.String(props.GlobalClusterIdentifier),
+		DbSubnetGroupName:           subnetGroup.SubnetGroupName(),
+		VpcSecurityGroupIds:         &[]*string{sg.SecurityGroupId()},
+		DbClusterParameterGroupName: parameterGroupName,
+		StorageEncrypted:            jsii.Bool(true),
+		KmsKeyId:                    keyArn,
+		DeletionProtection:          jsii.Bool(props.DeletionProtection),
+		CopyTagsToSnapshot:          jsii.Bool(true),
+	})
+	// Snapshots cannot be taken of secondary clusters, the data is retained by the primary cluster.
+	cluster.ApplyRemovalPolicy(awscdk.RemovalPolicy_DESTROY, nil)
+
+	instanceClass := "db.serverless"
+	if variant.Capacity == ServerlessV2 {
+		cluster.SetServerlessV2ScalingConfiguration(&awsrds.CfnDBCluster_ServerlessV2ScalingConfigurationProperty{
+			MinCapacity: jsii.Number(props.AuroraCapacityUnitsV2Min),
+			MaxCapacity: jsii.Number(props.AuroraCapacityUnitsV2Max),
+		})
+	} else {
+		instanceType := awsec2.InstanceType_Of(ComputeClassMap[props.InstanceClass], InstanceSizeMap[props.InstanceSize])
+		instanceClass = fmt.Sprintf("db.%s", *instanceType.ToString())
+	}
+
+	instance := awsrds.NewCfnDBInstance(stack, jsii.String("Instance"), &awsrds.CfnDBInstanceProps{
+		DbClusterIdentifier:       cluster.Ref(),
+		DbInstanceClass:           jsii.String(instanceClass),
+		Engine:                    variant.Engine.EngineType(),
+		DbSubnetGroupName:         subnetGroup.SubnetGroupName(),
+		DbParameterGroupName:      instanceParameterGroupName,
+		EnablePerformanceInsights: jsii.Bool(props.EnablePerformanceInsights),
+		PubliclyAccessible:        jsii.Bool(false),
+	})
+	instance.ApplyRemovalPolicy(awscdk.RemovalPolicy_DESTROY, nil)
+
+	awscdk.Aspects_Of(cluster).Add(NewMaintenanceAspect(props))
+
+	awscdk.NewCfnOutput(stack, jsii.String("host"), &awscdk.CfnOutputProps{
+		Value: cluster.AttrReadEndpointAddress(),
+	})
+	awscdk.NewCfnOutput(stack, jsii.String("port"), &awscdk.CfnOutputProps{
+		Value: cluster.AttrEndpointPort(),
+	})
+	// The admin username is not an output, the admin secret of the primary cluster holds the username it was created
+	// with, which may differ from the adminUsername arg of this Acorn
+	if props.SourceAdminSecretArn != "" {
+		awscdk.NewCfnOutput(stack, jsii.String("adminpasswordarn"), &awscdk.CfnOutputProps{
+			Value: jsii.String(props.SourceAdminSecretArn),
+		})
+	}
+	awscdk.NewCfnOutput(stack, jsii.String("clusterid"), &awscdk.CfnOutputProps{
+		Value: cluster.Ref(),
+	})
+	awscdk.NewCfnOutput(stack, jsii.String("globalclusterid"), &awscdk.CfnOutputProps{
+		Value: jsii.String(props.GlobalClusterIdentifier),
+	})
+	awscdk.NewCfnOutput(stack, jsii.String("sourceregion"), &awscdk.CfnOutputProps{
+		Value: jsii.String(props.SourceRegion),
+	})
+	if key != nil {
+		awscdk.NewCfnOutput(stack, jsii.String("kmskeyarn"), &awscdk.CfnOutputProps{
+			Value: key.KeyArn(),
+		})
+	}
+	AddConfigOutputs(stack, props)
+
+	return stack
+}
@@ -0,0 +1,167 @@
+package rds
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsrds"
+	"github.com/aws/constructs-go/constructs/v10"
+	"github.com/aws/jsii-runtime-go"
+)
+
+// RebootParametersMetadataKey is the template metadata key listing the static parameters a parameter group sets.
+// The cdk-runner flags changes to them in the change set output, as they only take effect after a reboot.
+const RebootParametersMetadataKey = "acorn.io/reboot-required-parameters"
+
+var (
+	parameterNameRegex = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_.]*$`)
+
+	// foreignParameters are parameters of the other engine family, that are obviously wrong for the engine.
+	// Entries ending in "_" or "." are prefixes.
+	foreignParameters = map[string][]string{
+		"aurora-mysql": {
+			"apg_", "pg_", "pgaudit.", "auto_explain.", "rds.", "shared_preload_libraries", "shared_buffers",
+			"work_mem", "maintenance_work_mem", "log_min_duration_statement", "search_path",
+		},
+		"aurora-postgresql": {
+			"innodb_", "binlog_", "server_audit_", "aurora_binlog_", "query_cache_", "character_set_", "collation_",
+			"performance_schema", "slow_query_log", "general_log", "long_query_time", "sql_mode",
+			"lower_case_table_names", "max_allowed_packet",
+		},
+	}
+	// clusterOnlyParameters can only be set in the cluster parameter group
+	clusterOnlyParameters = map[string][]string{
+		"aurora-mysql": {
+			"binlog_format", "lower_case_table_names", "server_audit_logging", "server_audit_events",
+			"server_audit_excl_users", "server_audit_incl_users",
+		},
+		"aurora-postgresql": {
+			"rds.logical_replication",
+		},
+	}
+	// StaticParameters are parameters that only take effect once the instances are rebooted
+	StaticParameters = map[string][]string{
+		"aurora-mysql": {
+			"binlog_format", "innodb_buffer_pool_size", "lower_case_table_names", "performance_schema",
+		},
+		"aurora-postgresql": {
+			"max_connections", "max_locks_per_transaction", "max_prepared_transactions", "max_worker_processes",
+			"rds.logical_replication", "shared_buffers", "shared_preload_libraries", "track_activity_query_size",
+		},
+	}
+)
+
+// clusterParameters returns the cluster parameters, including the ones set with the deprecated parameters field
+func (props *RDSStackProps) clusterParameters() map[string]string {
+	params := map[string]string{}
+	for k, v := range props.Parameters {
+		params[k] = v
+	}
+	for k, v := range props.ClusterParameters {
+		params[k] = v
+	}
+	return params
+}
+
+// ValidateParameters checks the cluster and instance parameters against the engine type, rejecting parameters of
+// the other engine family and cluster level parameters set on the instances.
+func ValidateParameters(engineType string, props *RDSStackProps) error {
+	var errs []error
+	for k := range props.Parameters {
+		if _, ok := props.ClusterParameters[k]; ok {
+			errs = append(errs, fmt.Errorf("parameter %s is set in both parameters and clusterParameters, parameters is deprecated in favor of clusterParameters", k))
+		}
+	}
+
+	for _, k := range sortedKeys(props.clusterParameters()) {
+		errs = append(errs, validateParameter(engineType, "clusterParameters", k)...)
+	}
+
+	for _, k := range sortedKeys(props.InstanceParameters) {
+		errs = append(errs, validateParameter(engineType, "instanceParameters", k)...)
+		if contains(clusterOnlyParameters[engineType], k) {
+			errs = append(errs, fmt.Errorf("instanceParameters: %s is a cluster parameter, set it in clusterParameters", k))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func validateParameter(engineType, field, name string) []error {
+	if !parameterNameRegex.MatchString(name) {
+		return []error{fmt.Errorf("%s: %q is not a valid parameter name", field, name)}
+	}
+
+	for _, foreign := range foreignParameters[engineType] {
+		if name == foreign || ((strings.HasSuffix(foreign, "_") || strings.HasSuffix(foreign, ".")) && strings.HasPrefix(name, foreign)) {
+			return []error{fmt.Errorf("%s: %s is not a parameter of the %s engine", field, name, engineType)}
+		}
+	}
+	return nil
+}
+
+// NewParameterGroup creates the cluster parameter group from clusterParameters
+func NewParameterGroup(scope constructs.Construct, name *string, props *RDSStackProps, engine awsrds.IClusterEngine) awsrds.ParameterGroup {
+	return newParameterGroup(scope, name, props.clusterParameters(), engine, "Acorn created RDS Parameter Group")
+}
+
+// NewInstanceParameterGroup creates the parameter group of the cluster instances from instanceParameters
+func NewInstanceParameterGroup(scope constructs.Construct, name *string, props *RDSStackProps, engine awsrds.IClusterEngine) awsrds.ParameterGroup {
+	return newParameterGroup(scope, name, props.InstanceParameters, engine, "Acorn created RDS Instance Parameter Group")
+}
+
+func newParameterGroup(scope constructs.Construct, name *string, params map[string]string, engine awsrds.IClusterEngine, description string) awsrds.ParameterGroup {
+	parameterGroup := awsrds.NewParameterGroup(scope, name, &awsrds.ParameterGroupProps{
+		Engine:      engine,
+		Description: jsii.String(description),
+		Parameters:  mapStringToMapStringPtr(params),
+	})
+
+	var static []string
+	for _, k := range sortedKeys(params) {
+		if contains(StaticParameters[*engine.EngineType()], k) {
+			static = append(static, k)
+		}
+	}
+	if len(static) > 0 {
+		awscdk.Aspects_Of(parameterGroup).Add(&RebootParametersAspect{Parameters: static})
+	}
+
+	return parameterGroup
+}
+
+// RebootParametersAspect records the static parameters a parameter group sets in the template metadata of the
+// parameter group, see RebootParametersMetadataKey.
+type RebootParametersAspect struct {
+	Parameters []string
+}
+
+func (ra *RebootParametersAspect) Visit(node constructs.IConstruct) {
+	switch n := node.(type) {
+	case awsrds.CfnDBClusterParameterGroup:
+		n.AddMetadata(jsii.String(RebootParametersMetadataKey), ra.Parameters)
+	case awsrds.CfnDBParameterGroup:
+		n.AddMetadata(jsii.String(RebootParametersMetadataKey), ra.Parameters)
+	}
+}
+
+func mapStringToMapStringPtr(from map[string]string) *map[string]*string {
+	to := &map[string]*string{}
+	for k, v := range from {
+		(*to)[k] = jsii.String(v)
+	}
+	return to
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
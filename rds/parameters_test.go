@@ -0,0 +1,88 @@
+package rds
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateParameters(t *testing.T) {
+	tests := []struct {
+		name        string
+		engineType  string
+		props       RDSStackProps
+		errContains string
+	}{
+		{
+			name:       "valid mysql parameters",
+			engineType: "aurora-mysql",
+			props: RDSStackProps{
+				Parameters:         map[string]string{"max_connections": "1000"},
+				ClusterParameters:  map[string]string{"binlog_format": "ROW"},
+				InstanceParameters: map[string]string{"innodb_buffer_pool_size": "{DBInstanceClassMemory*3/4}"},
+			},
+		},
+		{
+			name:       "valid postgres parameters",
+			engineType: "aurora-postgresql",
+			props: RDSStackProps{
+				ClusterParameters:  map[string]string{"rds.logical_replication": "1", "pg_stat_statements.track": "all"},
+				InstanceParameters: map[string]string{"shared_preload_libraries": "pg_stat_statements"},
+			},
+		},
+		{
+			name:       "parameter in parameters and clusterParameters",
+			engineType: "aurora-mysql",
+			props: RDSStackProps{
+				Parameters:        map[string]string{"max_connections": "1000"},
+				ClusterParameters: map[string]string{"max_connections": "2000"},
+			},
+			errContains: "parameter max_connections is set in both parameters and clusterParameters",
+		},
+		{
+			name:       "invalid parameter name",
+			engineType: "aurora-mysql",
+			props: RDSStackProps{
+				ClusterParameters: map[string]string{"max connections": "1000"},
+			},
+			errContains: `clusterParameters: "max connections" is not a valid parameter name`,
+		},
+		{
+			name:       "postgres parameter on mysql",
+			engineType: "aurora-mysql",
+			props: RDSStackProps{
+				ClusterParameters: map[string]string{"rds.force_ssl": "1"},
+			},
+			errContains: "clusterParameters: rds.force_ssl is not a parameter of the aurora-mysql engine",
+		},
+		{
+			name:       "mysql parameter on postgres",
+			engineType: "aurora-postgresql",
+			props: RDSStackProps{
+				InstanceParameters: map[string]string{"innodb_print_all_deadlocks": "1"},
+			},
+			errContains: "instanceParameters: innodb_print_all_deadlocks is not a parameter of the aurora-postgresql engine",
+		},
+		{
+			name:       "cluster parameter on the instances",
+			engineType: "aurora-mysql",
+			props: RDSStackProps{
+				InstanceParameters: map[string]string{"binlog_format": "ROW"},
+			},
+			errContains: "instanceParameters: binlog_format is a cluster parameter, set it in clusterParameters",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateParameters(tt.engineType, &tt.props); err != nil {
+				if tt.errContains == "" {
+					t.Errorf("unexpected error: %s", err)
+				} else if !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("expected error to contain %q, got %q", tt.errContains, err)
+				}
+			} else if tt.errContains != "" {
+				t.Errorf("expected error to contain %q, got nil", tt.errContains)
+			}
+		})
+	}
+}
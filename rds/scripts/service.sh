@@ -6,17 +6,62 @@ ADDRESS="$(       jq -r '.[] | select(.OutputKey=="host")            |.OutputVal
 ADMIN_USERNAME="$(jq -r '.[] | select(.OutputKey=="adminusername")   |.OutputValue' outputs.json )"
 PASSWORD_ARN="$(  jq -r '.[] | select(.OutputKey=="adminpasswordarn")|.OutputValue' outputs.json )"
 CLUSTER_ID="$(   jq -r '.[] | select(.OutputKey=="clusterid")      |.OutputValue' outputs.json )"
+KMS_KEY_ARN="$(  jq -r '.[] | select(.OutputKey=="kmskeyarn")      |.OutputValue' outputs.json )"
+USER_PASSWORD_ARN="$(jq -r '.[] | select(.OutputKey=="userpasswordarn") |.OutputValue' outputs.json )"
+GLOBAL_CLUSTER_ID="$(jq -r '.[] | select(.OutputKey=="globalclusterid") |.OutputValue' outputs.json )"
+SOURCE_REGION="$(jq -r '.[] | select(.OutputKey=="sourceregion")   |.OutputValue' outputs.json )"
+LOGS_EXPORTS="$( jq -r '.[] | select(.OutputKey=="logsexports")    |.OutputValue' outputs.json )"
+LOGS_RETENTION_DAYS="$(jq -r '.[] | select(.OutputKey=="logsretentiondays") |.OutputValue' outputs.json )"
+ROTATION_DAYS="$(jq -r '.[] | select(.OutputKey=="rotationdays")   |.OutputValue' outputs.json )"
 
-ADMIN_PASSWORD="$(aws --output json secretsmanager get-secret-value --secret-id "${PASSWORD_ARN}" --query 'SecretString' | jq -r .|jq -r .password)"
+# RDS creates the log groups for exported logs on its own, so apply the retention here rather than in the stack
+if [ -n "${LOGS_RETENTION_DAYS}" ]; then
+  for LOG_TYPE in ${LOGS_EXPORTS//,/ }; do
+    LOG_GROUP="/aws/rds/cluster/${CLUSTER_ID}/${LOG_TYPE}"
+    aws logs create-log-group --log-group-name "${LOG_GROUP}" 2> /dev/null
+    aws logs put-retention-policy --log-group-name "${LOG_GROUP}" --retention-in-days "${LOGS_RETENTION_DAYS}"
+  done
+fi
+
+# Secondary region clusters of a global cluster share the admin secret of the primary cluster, and take the admin
+# username from it as well
+ADMIN_PASSWORD=""
+if [ -n "${PASSWORD_ARN}" ]; then
+  ADMIN_SECRET="$(aws --output json --region "${SOURCE_REGION:-${CDK_DEFAULT_REGION}}" secretsmanager get-secret-value --secret-id "${PASSWORD_ARN}" --query 'SecretString' | jq -r .)"
+  ADMIN_PASSWORD="$(echo "${ADMIN_SECRET}" | jq -r .password)"
+  if [ -n "${SOURCE_REGION}" ]; then
+    ADMIN_USERNAME="$(echo "${ADMIN_SECRET}" | jq -r .username)"
+  fi
+fi
+
+# Rotated credentials are only rendered into the Acorn secrets on the next deploy, so consumers may read the
+# current ones from AWS Secrets Manager
+CONSUMER_PERMISSIONS=""
+if [ -n "${ROTATION_DAYS}" ]; then
+  SECRET_ARNS="\"${PASSWORD_ARN}\""
+  if [ -n "${USER_PASSWORD_ARN}" ]; then
+    SECRET_ARNS="${SECRET_ARNS}, \"${USER_PASSWORD_ARN}\""
+  fi
+  CONSUMER_PERMISSIONS="consumer: permissions: rules: [{
+    apiGroups: [\"aws.acorn.io\"]
+    verbs: [\"secretsmanager:GetSecretValue\", \"secretsmanager:DescribeSecret\"]
+    resources: [${SECRET_ARNS}]
+  }]"
+fi
 
 cat > /run/secrets/output <<EOF
 services: rds: {
   default: true
   address: "${ADDRESS}"
   ports: [${PORT}]
+  ${CONSUMER_PERMISSIONS}
   data: {
     dbName: "${DB_NAME}"
     clusterId: "${CLUSTER_ID}"
+    adminSecretArn: "${PASSWORD_ARN}"
+    userSecretArn: "${USER_PASSWORD_ARN}"
+    kmsKeyArn: "${KMS_KEY_ARN}"
+    globalClusterId: "${GLOBAL_CLUSTER_ID}"
   }
 }
 
@@ -29,6 +74,24 @@ secrets: "admin": {
 }
 EOF
 
+# With rotation enabled the user credentials are managed in AWS Secrets Manager
+if [ -n "${USER_PASSWORD_ARN}" ]; then
+  USER_SECRET="$(aws --output json secretsmanager get-secret-value --secret-id "${USER_PASSWORD_ARN}" --query 'SecretString' | jq -r .)"
+  USER_USERNAME="$(echo "${USER_SECRET}" | jq -r .username)"
+  USER_PASSWORD="$(echo "${USER_SECRET}" | jq -r .password)"
+
+  cat >> /run/secrets/output <<EOF
+
+secrets: "user": {
+	type: "basic"
+	data: {
+    username: "${USER_USERNAME}"
+    password: "${USER_PASSWORD}"
+  }
+}
+EOF
+fi
+
 if [ -z "${DB_USERNAME}" ]; then
   echo 'services: rds: secrets: ["admin"]' >> /run/secrets/output
 else
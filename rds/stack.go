@@ -0,0 +1,304 @@
+package rds
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/acorn-io/services/aws/libs/common"
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsec2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awskms"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsrds"
+	"github.com/aws/constructs-go/constructs/v10"
+	"github.com/aws/jsii-runtime-go"
+)
+
+// Capacity is how the instances of an Aurora cluster are provisioned
+type Capacity int
+
+const (
+	// Provisioned clusters run a writer instance of instanceClass and instanceSize
+	Provisioned Capacity = iota
+	// ServerlessV1 clusters scale between auroraCapacityUnitsMin and auroraCapacityUnitsMax and pause when idle
+	ServerlessV1
+	// ServerlessV2 clusters run a serverless v2 writer instance scaling between auroraCapacityUnitsV2Min and auroraCapacityUnitsV2Max
+	ServerlessV2
+)
+
+var invalidSnapshotIDChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+var auroraCapacityUnits = map[int]awsrds.AuroraCapacityUnit{
+	1:   awsrds.AuroraCapacityUnit_ACU_1,
+	2:   awsrds.AuroraCapacityUnit_ACU_2,
+	4:   awsrds.AuroraCapacityUnit_ACU_4,
+	8:   awsrds.AuroraCapacityUnit_ACU_8,
+	16:  awsrds.AuroraCapacityUnit_ACU_16,
+	32:  awsrds.AuroraCapacityUnit_ACU_32,
+	64:  awsrds.AuroraCapacityUnit_ACU_64,
+	128: awsrds.AuroraCapacityUnit_ACU_128,
+	256: awsrds.AuroraCapacityUnit_ACU_256,
+	384: awsrds.AuroraCapacityUnit_ACU_384,
+}
+
+// EngineVariant describes the engine and capacity of an Aurora Acorn. It is all that differs between the Acorns,
+// everything else is built the same way by NewStack.
+type EngineVariant struct {
+	Engine   awsrds.IClusterEngine
+	Port     int
+	Capacity Capacity
+	// MigrationTarget is the variant the cluster is replaced with when migrateToServerlessV2 is set
+	MigrationTarget *EngineVariant
+}
+
+// Validate checks the props that depend on the engine variant
+func (v *EngineVariant) Validate(props *RDSStackProps) error {
+	var errs []error
+	switch v.Capacity {
+	case Provisioned:
+		if !ValidInstanceParameters(props.InstanceClass, props.InstanceSize) {
+			errs = append(errs, fmt.Errorf("invalid instance class or size provided, check acorn run [IMAGE] --help for valid options"))
+		}
+	case ServerlessV1:
+		for _, acu := range []int{props.AuroraCapacityUnitsMin, props.AuroraCapacityUnitsMax} {
+			if _, ok := auroraCapacityUnits[acu]; !ok {
+				errs = append(errs, fmt.Errorf("invalid ACU request must be 1, 2, 4, 8, 16, 32, 64, 128, 256, 384. Passed in: %d", acu))
+			}
+		}
+		if props.CreateGlobalCluster || props.IsGlobalSecondary() {
+			errs = append(errs, fmt.Errorf("Aurora Serverless v1 clusters cannot be part of a global cluster"))
+		}
+	}
+	if v.Capacity == ServerlessV1 && len(props.InstanceParameters) > 0 {
+		errs = append(errs, fmt.Errorf("instanceParameters are not supported by Aurora Serverless v1 clusters, which have no instances"))
+	}
+	if err := ValidateParameters(*v.Engine.EngineType(), props); err != nil {
+		errs = append(errs, err)
+	}
+	if props.MigrateToServerlessV2 && v.MigrationTarget == nil {
+		errs = append(errs, fmt.Errorf("migrateToServerlessV2 is only supported by Aurora Serverless v1 clusters"))
+	}
+	return errors.Join(errs...)
+}
+
+// migrate switches the props over to the migration target of the variant. The new cluster is restored from the
+// snapshot that the pre-change-set-apply hook takes of the current cluster, in place of any earlier restore.
+func (v *EngineVariant) migrate(props *RDSStackProps, stackName string) *EngineVariant {
+	props.RestoreSnapshotArn = ServerlessV2MigrationSnapshotID(stackName)
+	props.RestoreSourceClusterID = ""
+	props.RestoreToTime = ""
+	return v.MigrationTarget
+}
+
+// ServerlessV2MigrationSnapshotID returns the identifier of the snapshot a serverless v1 cluster is migrated to
+// serverless v2 through. It only depends on the stack name, so it stays the same once the migration is done.
+func ServerlessV2MigrationSnapshotID(stackName string) string {
+	id := strings.Trim(invalidSnapshotIDChars.ReplaceAllString(strings.ToLower(stackName), "-"), "-")
+	if id == "" || id[0] < 'a' || id[0] > 'z' {
+		id = "acorn-" + id
+	}
+	return strings.TrimSuffix(id, "-") + "-serverless-v2-migration"
+}
+
+// Cluster is implemented by both the provisioned and serverless Aurora clusters
+type Cluster interface {
+	RotatableCluster
+	constructs.IConstruct
+	ClusterEndpoint() awsrds.Endpoint
+	ClusterIdentifier() *string
+}
+
+// clusterResources are the resources shared by all engine variants that the cluster is created with
+type clusterResources struct {
+	vpc            awsec2.IVpc
+	subnetGroup    awsrds.ISubnetGroup
+	securityGroups *[]awsec2.ISecurityGroup
+	key            awskms.IKey
+	credentials    awsrds.Credentials
+	parameterGroup awsrds.IParameterGroup
+	// instanceParameterGroup is applied to the writer instance, serverless v1 clusters have no instances
+	instanceParameterGroup awsrds.IParameterGroup
+}
+
+func (v *EngineVariant) newCluster(scope constructs.Construct, name *string, props *RDSStackProps, res *clusterResources) Cluster {
+	if v.Capacity == ServerlessV1 {
+		return awsrds.NewServerlessCluster(scope, name, &awsrds.ServerlessClusterProps{
+			Engine:               v.Engine,
+			DefaultDatabaseName:  jsii.String(props.DatabaseName),
+			CopyTagsToSnapshot:   jsii.Bool(true),
+			DeletionProtection:   jsii.Bool(props.DeletionProtection),
+			RemovalPolicy:        GetRemovalPolicy(props),
+			StorageEncryptionKey: res.key,
+			Credentials:          res.credentials,
+			Vpc:                  res.vpc,
+			Scaling: &awsrds.ServerlessScalingOptions{
+				AutoPause:   awscdk.Duration_Minutes(jsii.Number(props.AutoPauseDurationMinutes)),
+				MinCapacity: auroraCapacityUnits[props.AuroraCapacityUnitsMin],
+				MaxCapacity: auroraCapacityUnits[props.AuroraCapacityUnitsMax],
+			},
+			SubnetGroup:    res.subnetGroup,
+			SecurityGroups: res.securityGroups,
+			ParameterGroup: res.parameterGroup,
+		})
+	}
+
+	clusterProps := &awsrds.DatabaseClusterProps{
+		Engine:               v.Engine,
+		DefaultDatabaseName:  jsii.String(props.DatabaseName),
+		CopyTagsToSnapshot:   jsii.Bool(true),
+		Credentials:          res.credentials,
+		DeletionProtection:   jsii.Bool(props.DeletionProtection),
+		RemovalPolicy:        GetRemovalPolicy(props),
+		StorageEncryptionKey: res.key,
+		SubnetGroup:          res.subnetGroup,
+		Vpc:                  res.vpc,
+		SecurityGroups:       res.securityGroups,
+		ParameterGroup:       res.parameterGroup,
+	}
+
+	if v.Capacity == ServerlessV2 {
+		clusterProps.ServerlessV2MinCapacity = jsii.Number(props.AuroraCapacityUnitsV2Min)
+		clusterProps.ServerlessV2MaxCapacity = jsii.Number(props.AuroraCapacityUnitsV2Max)
+		clusterProps.Writer = awsrds.ClusterInstance_ServerlessV2(jsii.String("Instance"), &awsrds.ServerlessV2ClusterInstanceProps{
+			EnablePerformanceInsights: jsii.Bool(props.EnablePerformanceInsights),
+			ParameterGroup:            res.instanceParameterGroup,
+		})
+	} else {
+		clusterProps.Writer = awsrds.ClusterInstance_Provisioned(jsii.String("Instance"), &awsrds.ProvisionedClusterInstanceProps{
+			InstanceType:              awsec2.InstanceType_Of(ComputeClassMap[props.InstanceClass], InstanceSizeMap[props.InstanceSize]),
+			IsFromLegacyInstanceProps: jsii.Bool(true),
+			EnablePerformanceInsights: jsii.Bool(props.EnablePerformanceInsights),
+			ParameterGroup:            res.instanceParameterGroup,
+		})
+	}
+
+	return awsrds.NewDatabaseCluster(scope, name, clusterProps)
+}
+
+// NewStack creates the stack of an Aurora Acorn for the given engine variant. If sourceRegion is set, the stack
+// joins an existing global cluster as a secondary region instead, see NewGlobalSecondaryStack.
+func NewStack(scope constructs.Construct, props *RDSStackProps, variant *EngineVariant) awscdk.Stack {
+	if props.IsGlobalSecondary() {
+		return NewGlobalSecondaryStack(scope, props, variant)
+	}
+
+	var sprops awscdk.StackProps
+	if props != nil {
+		sprops = props.StackProps
+	}
+
+	stack := awscdk.NewStack(scope, jsii.String("Stack"), &sprops)
+
+	vpc := LookupVpc(stack, jsii.String("VPC"), props.VpcID)
+
+	subnetGroup := GetPrivateSubnetGroup(stack, jsii.String("SubnetGroup"), vpc)
+
+	sg := common.GetIngressSecurityGroup(stack, jsii.String("SG"), jsii.String("Acorn generated RDS security group."), vpc, variant.Port, &props.IngressProps)
+
+	key := GetStorageEncryptionKey(stack, jsii.String("Key"), props)
+
+	creds := awsrds.Credentials_FromGeneratedSecret(jsii.String(props.AdminUser), &awsrds.CredentialsBaseOptions{})
+
+	var parameterGroup awsrds.IParameterGroup
+	if len(props.clusterParameters()) > 0 {
+		parameterGroup = NewParameterGroup(stack, jsii.String("ParameterGroup"), props, variant.Engine)
+	}
+
+	var instanceParameterGroup awsrds.IParameterGroup
+	if len(props.InstanceParameters) > 0 {
+		instanceParameterGroup = NewInstanceParameterGroup(stack, jsii.String("InstanceParameterGroup"), props, variant.Engine)
+	}
+
+	cluster := variant.newCluster(stack, jsii.String("Cluster"), props, &clusterResources{
+		vpc:                    vpc,
+		subnetGroup:            subnetGroup,
+		securityGroups:         &[]awsec2.ISecurityGroup{sg},
+		key:                    key,
+		credentials:            creds,
+		parameterGroup:         parameterGroup,
+		instanceParameterGroup: instanceParameterGroup,
+	})
+
+	userSecret := AddSecretRotation(stack, cluster, props, sg)
+
+	if props.RestoreSnapshotArn != "" {
+		awscdk.Aspects_Of(cluster).Add(NewSnapshotAspect(props.RestoreSnapshotArn))
+	}
+	if props.RestoreSourceClusterID != "" {
+		awscdk.Aspects_Of(cluster).Add(NewPointInTimeRestoreAspect(props.RestoreSourceClusterID, props.RestoreToTime))
+	}
+	awscdk.Aspects_Of(cluster).Add(NewMaintenanceAspect(props))
+
+	port := strconv.Itoa(variant.Port)
+	pSlice := strings.SplitN(*cluster.ClusterEndpoint().SocketAddress(), ":", 2)
+	if len(pSlice) == 2 {
+		port = pSlice[1]
+	}
+
+	awscdk.NewCfnOutput(stack, jsii.String("host"), &awscdk.CfnOutputProps{
+		Value: cluster.ClusterEndpoint().Hostname(),
+	})
+	awscdk.NewCfnOutput(stack, jsii.String("port"), &awscdk.CfnOutputProps{
+		Value: &port,
+	})
+	awscdk.NewCfnOutput(stack, jsii.String("adminusername"), &awscdk.CfnOutputProps{
+		Value: creds.Username(),
+	})
+	awscdk.NewCfnOutput(stack, jsii.String("adminpasswordarn"), &awscdk.CfnOutputProps{
+		Value: cluster.Secret().SecretArn(),
+	})
+	awscdk.NewCfnOutput(stack, jsii.String("clusterid"), &awscdk.CfnOutputProps{
+		Value: cluster.ClusterIdentifier(),
+	})
+	if key != nil {
+		awscdk.NewCfnOutput(stack, jsii.String("kmskeyarn"), &awscdk.CfnOutputProps{
+			Value: key.KeyArn(),
+		})
+	}
+	if userSecret != nil {
+		awscdk.NewCfnOutput(stack, jsii.String("userpasswordarn"), &awscdk.CfnOutputProps{
+			Value: userSecret.SecretArn(),
+		})
+	}
+	AddConfigOutputs(stack, props)
+
+	if props.CreateGlobalCluster {
+		NewGlobalCluster(stack, jsii.String("GlobalCluster"), cluster, props)
+	}
+
+	return stack
+}
+
+// Main reads the Acorn config, validates it and synthesizes the stack for the given engine variant.
+// It is the whole main function of each Aurora Acorn.
+func Main(variant *EngineVariant) {
+	common.RunStack(NewStackProps(variant, *common.NewAWSCDKStackProps()), func(scope constructs.Construct, props *RDSStackProps) error {
+		NewVariantStack(scope, props)
+		return nil
+	})
+}
+
+// NewStackProps returns the props of a stack of the engine variant, for the config to be decoded into
+func NewStackProps(variant *EngineVariant, stackProps awscdk.StackProps) *RDSStackProps {
+	return &RDSStackProps{
+		StackProps: stackProps,
+		variant:    variant,
+	}
+}
+
+// NewVariantStack creates the stack of the engine variant of the props, or of its migration target when
+// migrateToServerlessV2 is set
+func NewVariantStack(scope constructs.Construct, props *RDSStackProps) awscdk.Stack {
+	variant := props.variant
+	if props.MigrateToServerlessV2 {
+		variant = variant.migrate(props, os.Getenv("ACORN_EXTERNAL_ID"))
+	}
+	stack := NewStack(scope, props, variant)
+	stack.TemplateOptions().SetMetadata(&map[string]interface{}{
+		DriftPolicyMetadataKey: props.RunnerDriftPolicy(),
+	})
+	return stack
+}
@@ -0,0 +1,553 @@
+package rds
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-cdk-go/awscdk/v2"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsrds"
+	"github.com/aws/jsii-runtime-go"
+)
+
+var (
+	testMysqlEngine = awsrds.DatabaseClusterEngine_AuroraMysql(&awsrds.AuroraMysqlClusterEngineProps{
+		Version: awsrds.AuroraMysqlEngineVersion_VER_3_03_0(),
+	})
+	testPostgresEngine = awsrds.DatabaseClusterEngine_AuroraPostgres(&awsrds.AuroraPostgresClusterEngineProps{
+		Version: awsrds.AuroraPostgresEngineVersion_VER_15_3(),
+	})
+
+	mysqlCluster      = &EngineVariant{Engine: testMysqlEngine, Port: 3306, Capacity: Provisioned}
+	mysqlServerlessV2 = &EngineVariant{Engine: testMysqlEngine, Port: 3306, Capacity: ServerlessV2}
+	mysqlServerlessV1 = &EngineVariant{Engine: awsrds.DatabaseClusterEngine_AURORA_MYSQL(), Port: 3306, Capacity: ServerlessV1, MigrationTarget: mysqlServerlessV2}
+	postgresCluster   = &EngineVariant{Engine: testPostgresEngine, Port: 5432, Capacity: Provisioned}
+)
+
+func newTestProps() *RDSStackProps {
+	return &RDSStackProps{
+		StackProps: awscdk.StackProps{
+			Env: &awscdk.Environment{
+				Account: jsii.String("123456789012"),
+				Region:  jsii.String("us-east-1"),
+			},
+		},
+		AdminUser:                "admin",
+		DatabaseName:             "instance",
+		InstanceClass:            "burstable",
+		InstanceSize:             "medium",
+		VpcID:                    "vpc-123",
+		AuroraCapacityUnitsMin:   1,
+		AuroraCapacityUnitsMax:   2,
+		AuroraCapacityUnitsV2Min: 0.5,
+		AuroraCapacityUnitsV2Max: 2,
+	}
+}
+
+func synth(props *RDSStackProps, variant *EngineVariant) assertions.Template {
+	return assertions.Template_FromStack(NewStack(awscdk.NewApp(nil), props, variant), nil)
+}
+
+func TestNewStackVariants(t *testing.T) {
+	tests := []struct {
+		name     string
+		variant  *EngineVariant
+		cluster  map[string]interface{}
+		instance map[string]interface{}
+	}{
+		{
+			name:    "mysql cluster",
+			variant: mysqlCluster,
+			cluster: map[string]interface{}{
+				"Engine":        "aurora-mysql",
+				"EngineVersion": "8.0.mysql_aurora.3.03.0",
+				"DatabaseName":  "instance",
+			},
+			instance: map[string]interface{}{
+				"DBInstanceClass": "db.t3.medium",
+			},
+		},
+		{
+			name:    "mysql serverless v1",
+			variant: mysqlServerlessV1,
+			cluster: map[string]interface{}{
+				"Engine":     "aurora-mysql",
+				"EngineMode": "serverless",
+				"ScalingConfiguration": map[string]interface{}{
+					"MinCapacity": 1,
+					"MaxCapacity": 2,
+				},
+			},
+		},
+		{
+			name:    "mysql serverless v2",
+			variant: mysqlServerlessV2,
+			cluster: map[string]interface{}{
+				"Engine": "aurora-mysql",
+				"ServerlessV2ScalingConfiguration": map[string]interface{}{
+					"MinCapacity": 0.5,
+					"MaxCapacity": 2,
+				},
+			},
+			instance: map[string]interface{}{
+				"DBInstanceClass": "db.serverless",
+			},
+		},
+		{
+			name:    "postgres cluster",
+			variant: postgresCluster,
+			cluster: map[string]interface{}{
+				"Engine":        "aurora-postgresql",
+				"EngineVersion": "15.3",
+			},
+			instance: map[string]interface{}{
+				"DBInstanceClass": "db.t3.medium",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			template := synth(newTestProps(), tt.variant)
+
+			template.HasResourceProperties(jsii.String("AWS::RDS::DBCluster"), tt.cluster)
+			template.HasResource(jsii.String("AWS::RDS::DBCluster"), map[string]interface{}{
+				"DeletionPolicy": "Snapshot",
+			})
+			template.HasResourceProperties(jsii.String("AWS::EC2::SecurityGroup"), map[string]interface{}{
+				"SecurityGroupIngress": assertions.Match_ArrayWith(&[]interface{}{
+					assertions.Match_ObjectLike(&map[string]interface{}{
+						"FromPort": tt.variant.Port,
+						"ToPort":   tt.variant.Port,
+					}),
+				}),
+			})
+			if tt.instance != nil {
+				template.HasResourceProperties(jsii.String("AWS::RDS::DBInstance"), tt.instance)
+			} else {
+				template.ResourceCountIs(jsii.String("AWS::RDS::DBInstance"), jsii.Number(0))
+			}
+			for _, output := range []string{"host", "port", "adminusername", "adminpasswordarn", "clusterid"} {
+				template.HasOutput(jsii.String(output), map[string]interface{}{})
+			}
+		})
+	}
+}
+
+func TestNewStackFeatures(t *testing.T) {
+	for _, variant := range []*EngineVariant{mysqlCluster, mysqlServerlessV1, mysqlServerlessV2, postgresCluster} {
+		props := newTestProps()
+		props.RegularUser = "user"
+		props.RotationDays = 30
+		props.CreateKey = true
+		props.RestoreSnapshotArn = "arn:aws:rds:us-east-1:123456789012:cluster-snapshot:snapshot"
+		props.BackupRetentionDays = 7
+		props.Parameters = map[string]string{"max_connections": "1000"}
+
+		template := synth(props, variant)
+
+		template.HasResourceProperties(jsii.String("AWS::RDS::DBCluster"), map[string]interface{}{
+			"SnapshotIdentifier":    props.RestoreSnapshotArn,
+			"BackupRetentionPeriod": 7,
+			"StorageEncrypted":      true,
+			"KmsKeyId":              assertions.Match_AnyValue(),
+		})
+		template.ResourceCountIs(jsii.String("AWS::KMS::Key"), jsii.Number(1))
+		template.ResourceCountIs(jsii.String("AWS::Serverless::Application"), jsii.Number(2))
+		template.HasResourceProperties(jsii.String("AWS::RDS::DBClusterParameterGroup"), map[string]interface{}{
+			"Parameters": map[string]interface{}{"max_connections": "1000"},
+		})
+		template.HasOutput(jsii.String("kmskeyarn"), map[string]interface{}{})
+		template.HasOutput(jsii.String("userpasswordarn"), map[string]interface{}{})
+	}
+}
+
+func TestNewStackRotationFunctionNames(t *testing.T) {
+	t.Setenv("ACORN_EXTERNAL_ID", "my-app-my-project-1a2b3c")
+	props := newTestProps()
+	props.RegularUser = "user"
+	props.RotationDays = 30
+
+	template := synth(props, mysqlCluster)
+
+	// The Acornfiles only grant the apply job the functions named with the prefix
+	for _, name := range []string{"acorn-rds-rotation-my-app-my-project-1a2b3c-admin", "acorn-rds-rotation-my-app-my-project-1a2b3c-user"} {
+		template.HasResourceProperties(jsii.String("AWS::Serverless::Application"), map[string]interface{}{
+			"Parameters": assertions.Match_ObjectLike(&map[string]interface{}{"functionName": name}),
+		})
+	}
+}
+
+func TestNewStackSnapshotSharing(t *testing.T) {
+	props := newTestProps()
+	props.CreateKey = true
+	props.ShareSnapshotWithAccounts = []string{"111111111111", "222222222222"}
+
+	template := synth(props, mysqlCluster)
+
+	for _, account := range props.ShareSnapshotWithAccounts {
+		template.HasResourceProperties(jsii.String("AWS::KMS::Key"), map[string]interface{}{
+			"KeyPolicy": map[string]interface{}{
+				"Statement": assertions.Match_ArrayWith(&[]interface{}{
+					assertions.Match_ObjectLike(&map[string]interface{}{
+						"Action": []interface{}{"kms:Decrypt", "kms:CreateGrant", "kms:DescribeKey"},
+						"Effect": "Allow",
+						"Principal": map[string]interface{}{
+							"AWS": map[string]interface{}{
+								"Fn::Join": []interface{}{"", []interface{}{"arn:", map[string]interface{}{"Ref": "AWS::Partition"}, ":iam::" + account + ":root"}},
+							},
+						},
+					}),
+				}),
+			},
+		})
+	}
+}
+
+func TestNewStackConfigOutputs(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(props *RDSStackProps)
+		want   map[string]string
+	}{
+		{
+			name:   "defaults",
+			modify: func(props *RDSStackProps) {},
+			want:   map[string]string{},
+		},
+		{
+			name: "log retention",
+			modify: func(props *RDSStackProps) {
+				props.CloudwatchLogsExports = []string{"error", "slowquery"}
+				props.CloudwatchLogsRetentionDays = 30
+			},
+			want: map[string]string{"logsexports": "error,slowquery", "logsretentiondays": "30"},
+		},
+		{
+			name: "log exports without retention",
+			modify: func(props *RDSStackProps) {
+				props.CloudwatchLogsExports = []string{"error"}
+			},
+			want: map[string]string{},
+		},
+		{
+			name: "rotation and snapshot sharing",
+			modify: func(props *RDSStackProps) {
+				props.RotationDays = 30
+				props.CreateKey = true
+				props.ShareSnapshotWithAccounts = []string{"111111111111", "222222222222"}
+			},
+			want: map[string]string{"rotationdays": "30", "sharesnapshotaccounts": "111111111111,222222222222"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			props := newTestProps()
+			tt.modify(props)
+
+			template := synth(props, mysqlCluster)
+
+			// The service script and the post-delete hook read these instead of the config file
+			for _, name := range []string{"logsexports", "logsretentiondays", "rotationdays", "sharesnapshotaccounts"} {
+				outputs := template.FindOutputs(jsii.String(name), nil)
+				value, ok := tt.want[name]
+				if !ok {
+					if len(*outputs) != 0 {
+						t.Errorf("expected no %s output, got %v", name, *outputs)
+					}
+					continue
+				}
+				template.HasOutput(jsii.String(name), map[string]interface{}{"Value": value})
+			}
+		})
+	}
+}
+
+func TestNewStackIngress(t *testing.T) {
+	template := synth(newTestProps(), mysqlCluster)
+	template.HasResourceProperties(jsii.String("AWS::EC2::SecurityGroup"), map[string]interface{}{
+		"SecurityGroupIngress": assertions.Match_ArrayWith(&[]interface{}{
+			assertions.Match_ObjectLike(&map[string]interface{}{"Description": "Allow from private subnets"}),
+		}),
+	})
+	template.HasResourceProperties(jsii.String("AWS::EC2::SecurityGroup"), map[string]interface{}{
+		"SecurityGroupIngress": assertions.Match_Not(assertions.Match_ArrayWith(&[]interface{}{
+			assertions.Match_ObjectLike(&map[string]interface{}{"Description": "Allow from public subnets"}),
+		})),
+	})
+
+	props := newTestProps()
+	props.AllowedCIDRs = []string{"10.1.0.0/16"}
+	props.AllowedSecurityGroupIDs = []string{"sg-0123456789abcdef0"}
+	props.AllowedPrefixListIDs = []string{"pl-63a5400a"}
+
+	template = synth(props, mysqlCluster)
+	template.HasResourceProperties(jsii.String("AWS::EC2::SecurityGroup"), map[string]interface{}{
+		"SecurityGroupIngress": assertions.Match_ArrayEquals(&[]interface{}{
+			assertions.Match_ObjectLike(&map[string]interface{}{"CidrIp": "10.1.0.0/16", "FromPort": 3306}),
+			assertions.Match_ObjectLike(&map[string]interface{}{"SourceSecurityGroupId": "sg-0123456789abcdef0", "FromPort": 3306}),
+		}),
+	})
+	template.HasResourceProperties(jsii.String("AWS::EC2::SecurityGroupIngress"), map[string]interface{}{
+		"SourcePrefixListId": "pl-63a5400a",
+		"FromPort":           3306,
+	})
+}
+
+func TestNewStackParameterGroups(t *testing.T) {
+	props := newTestProps()
+	props.ClusterParameters = map[string]string{"binlog_format": "ROW", "max_connections": "1000"}
+	props.InstanceParameters = map[string]string{"performance_schema": "1"}
+
+	template := synth(props, mysqlCluster)
+
+	template.HasResource(jsii.String("AWS::RDS::DBClusterParameterGroup"), map[string]interface{}{
+		"Metadata": map[string]interface{}{
+			RebootParametersMetadataKey: []interface{}{"binlog_format"},
+		},
+		"Properties": assertions.Match_ObjectLike(&map[string]interface{}{
+			"Parameters": map[string]interface{}{"binlog_format": "ROW", "max_connections": "1000"},
+		}),
+	})
+	template.HasResource(jsii.String("AWS::RDS::DBParameterGroup"), map[string]interface{}{
+		"Metadata": map[string]interface{}{
+			RebootParametersMetadataKey: []interface{}{"performance_schema"},
+		},
+		"Properties": assertions.Match_ObjectLike(&map[string]interface{}{
+			"Parameters": map[string]interface{}{"performance_schema": "1"},
+		}),
+	})
+	template.HasResourceProperties(jsii.String("AWS::RDS::DBInstance"), map[string]interface{}{
+		"DBParameterGroupName": assertions.Match_AnyValue(),
+	})
+}
+
+func TestNewStackGlobalCluster(t *testing.T) {
+	props := newTestProps()
+	props.CreateGlobalCluster = true
+	props.GlobalClusterIdentifier = "global-db"
+
+	template := synth(props, mysqlServerlessV2)
+
+	template.HasResourceProperties(jsii.String("AWS::RDS::GlobalCluster"), map[string]interface{}{
+		"GlobalClusterIdentifier":   "global-db",
+		"SourceDBClusterIdentifier": assertions.Match_AnyValue(),
+	})
+	template.HasOutput(jsii.String("globalclusterid"), map[string]interface{}{})
+}
+
+func TestNewStackGlobalSecondary(t *testing.T) {
+	tests := []struct {
+		name          string
+		variant       *EngineVariant
+		instanceClass string
+	}{
+		{
+			name:          "provisioned",
+			variant:       postgresCluster,
+			instanceClass: "db.t3.medium",
+		},
+		{
+			name:          "serverless v2",
+			variant:       mysqlServerlessV2,
+			instanceClass: "db.serverless",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			props := newTestProps()
+			props.GlobalClusterIdentifier = "global-db"
+			props.SourceRegion = "us-west-2"
+			props.SourceAdminSecretArn = "arn:aws:secretsmanager:us-west-2:123456789012:secret:admin-AbCdEf"
+
+			template := synth(props, tt.variant)
+
+			template.HasResource(jsii.String("AWS::RDS::DBCluster"), map[string]interface{}{
+				"DeletionPolicy": "Delete",
+				"Properties": assertions.Match_ObjectLike(&map[string]interface{}{
+					"GlobalClusterIdentifier": "global-db",
+					"MasterUsername":          assertions.Match_Absent(),
+				}),
+			})
+			template.HasResourceProperties(jsii.String("AWS::RDS::DBInstance"), map[string]interface{}{
+				"DBInstanceClass": tt.instanceClass,
+			})
+			template.ResourceCountIs(jsii.String("AWS::SecretsManager::Secret"), jsii.Number(0))
+			template.HasOutput(jsii.String("host"), map[string]interface{}{
+				"Value": map[string]interface{}{
+					"Fn::GetAtt": []interface{}{assertions.Match_AnyValue(), "ReadEndpoint.Address"},
+				},
+			})
+			template.HasOutput(jsii.String("adminpasswordarn"), map[string]interface{}{
+				"Value": props.SourceAdminSecretArn,
+			})
+			// The admin username is read from the secret of the primary cluster, not from the adminUsername arg
+			if outputs := template.FindOutputs(jsii.String("adminusername"), nil); len(*outputs) != 0 {
+				t.Errorf("expected no adminusername output, got %v", *outputs)
+			}
+		})
+	}
+}
+
+func TestNewStackServerlessV2Migration(t *testing.T) {
+	props := newTestProps()
+	props.MigrateToServerlessV2 = true
+	props.RestoreSnapshotArn = "arn:aws:rds:us-east-1:123456789012:cluster-snapshot:snapshot"
+
+	v1 := synth(newTestProps(), mysqlServerlessV1)
+	v2 := synth(props, mysqlServerlessV1.migrate(props, "Acorn-Stack"))
+
+	v2.HasResourceProperties(jsii.String("AWS::RDS::DBCluster"), map[string]interface{}{
+		"SnapshotIdentifier": "acorn-stack-serverless-v2-migration",
+		"EngineMode":         assertions.Match_Absent(),
+		"EngineVersion":      "8.0.mysql_aurora.3.03.0",
+	})
+	v2.HasResourceProperties(jsii.String("AWS::RDS::DBInstance"), map[string]interface{}{
+		"DBInstanceClass": "db.serverless",
+	})
+
+	// The cdk-runner must allow the replacement of the cluster
+	v2.HasResource(jsii.String("AWS::RDS::DBCluster"), map[string]interface{}{
+		"Metadata": map[string]interface{}{AllowReplacementMetadataKey: []interface{}{"SnapshotIdentifier"}},
+	})
+	v1.HasResource(jsii.String("AWS::RDS::DBCluster"), map[string]interface{}{
+		"Metadata": assertions.Match_Absent(),
+	})
+
+	// The secret and the cluster keep their logical IDs, so the secret is kept and the cluster is replaced in place
+	for _, resourceType := range []string{"AWS::RDS::DBCluster", "AWS::SecretsManager::Secret"} {
+		before := v1.FindResources(jsii.String(resourceType), nil)
+		after := v2.FindResources(jsii.String(resourceType), nil)
+		for logicalID := range *before {
+			if _, ok := (*after)[logicalID]; !ok {
+				t.Errorf("%s %s is removed by the migration", resourceType, logicalID)
+			}
+		}
+	}
+}
+
+func TestNewVariantStackDriftPolicy(t *testing.T) {
+	tests := map[string]string{
+		"":       "warn",
+		"fail":   "block",
+		"warn":   "warn",
+		"ignore": "off",
+	}
+
+	for driftPolicy, want := range tests {
+		t.Run(driftPolicy, func(t *testing.T) {
+			props := newTestProps()
+			props.variant = mysqlCluster
+			props.DriftPolicy = driftPolicy
+
+			template := assertions.Template_FromStack(NewVariantStack(awscdk.NewApp(nil), props), nil)
+			metadata, _ := (*template.ToJSON())["Metadata"].(map[string]interface{})
+			if got := metadata[DriftPolicyMetadataKey]; got != want {
+				t.Errorf("expected the drift policy to be %s, got %v", want, got)
+			}
+		})
+	}
+}
+
+func TestServerlessV2MigrationSnapshotID(t *testing.T) {
+	tests := map[string]string{
+		"my-stack":      "my-stack-serverless-v2-migration",
+		"My_Stack.Name": "my-stack-name-serverless-v2-migration",
+		"1234-abcd":     "acorn-1234-abcd-serverless-v2-migration",
+		"--stack--":     "stack-serverless-v2-migration",
+		"":              "acorn-serverless-v2-migration",
+	}
+
+	for stackName, expected := range tests {
+		if id := ServerlessV2MigrationSnapshotID(stackName); id != expected {
+			t.Errorf("expected snapshot ID for %q to be %q, got %q", stackName, expected, id)
+		}
+	}
+}
+
+func TestEngineVariantValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		variant     *EngineVariant
+		modify      func(*RDSStackProps)
+		errContains string
+	}{
+		{
+			name:    "valid provisioned",
+			variant: mysqlCluster,
+		},
+		{
+			name:        "invalid instance size",
+			variant:     postgresCluster,
+			modify:      func(p *RDSStackProps) { p.InstanceSize = "huge" },
+			errContains: "invalid instance class or size provided",
+		},
+		{
+			name:    "serverless ignores instance size",
+			variant: mysqlServerlessV2,
+			modify:  func(p *RDSStackProps) { p.InstanceSize = "" },
+		},
+		{
+			name:        "invalid serverless v1 capacity",
+			variant:     mysqlServerlessV1,
+			modify:      func(p *RDSStackProps) { p.AuroraCapacityUnitsMax = 3 },
+			errContains: "invalid ACU request must be 1, 2, 4, 8, 16, 32, 64, 128, 256, 384. Passed in: 3",
+		},
+		{
+			name:        "serverless v1 global cluster",
+			variant:     mysqlServerlessV1,
+			modify:      func(p *RDSStackProps) { p.CreateGlobalCluster = true },
+			errContains: "cannot be part of a global cluster",
+		},
+		{
+			name:    "serverless v1 migration",
+			variant: mysqlServerlessV1,
+			modify:  func(p *RDSStackProps) { p.MigrateToServerlessV2 = true },
+		},
+		{
+			name:        "migration without a target",
+			variant:     mysqlCluster,
+			modify:      func(p *RDSStackProps) { p.MigrateToServerlessV2 = true },
+			errContains: "migrateToServerlessV2 is only supported by Aurora Serverless v1 clusters",
+		},
+		{
+			name:        "serverless v1 instance parameters",
+			variant:     mysqlServerlessV1,
+			modify:      func(p *RDSStackProps) { p.InstanceParameters = map[string]string{"max_connections": "1000"} },
+			errContains: "instanceParameters are not supported by Aurora Serverless v1 clusters",
+		},
+		{
+			name:        "postgres parameter on mysql",
+			variant:     mysqlServerlessV2,
+			modify:      func(p *RDSStackProps) { p.ClusterParameters = map[string]string{"shared_buffers": "1024"} },
+			errContains: "clusterParameters: shared_buffers is not a parameter of the aurora-mysql engine",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			props := newTestProps()
+			if tt.modify != nil {
+				tt.modify(props)
+			}
+			if err := tt.variant.Validate(props); err != nil {
+				if tt.errContains == "" {
+					t.Errorf("unexpected error: %s", err)
+				} else if !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("expected error to contain %q, got %q", tt.errContains, err)
+				}
+			} else if tt.errContains != "" {
+				t.Errorf("expected error to contain %q, got nil", tt.errContains)
+			}
+		})
+	}
+}
+
+func TestServerlessV1MigrationTarget(t *testing.T) {
+	// The migrated cluster must match the one the serverless v2 Acorn creates, or its next update replaces it
+	if AuroraMySQLServerlessV1.MigrationTarget != AuroraMySQLServerlessV2 {
+		t.Errorf("expected serverless v1 clusters to migrate to the serverless v2 variant, got %+v", AuroraMySQLServerlessV1.MigrationTarget)
+	}
+}
@@ -0,0 +1,37 @@
+package rds
+
+import (
+	"github.com/aws/aws-cdk-go/awscdk/v2/awsrds"
+)
+
+// The engine variants of the Aurora Acorns, each built from its directory under aurora
+var (
+	AuroraMySQLCluster = &EngineVariant{
+		Engine: awsrds.DatabaseClusterEngine_AuroraMysql(&awsrds.AuroraMysqlClusterEngineProps{
+			Version: awsrds.AuroraMysqlEngineVersion_VER_3_03_0(),
+		}),
+		Port:     3306,
+		Capacity: Provisioned,
+	}
+	AuroraMySQLServerlessV2 = &EngineVariant{
+		Engine: awsrds.DatabaseClusterEngine_AuroraMysql(&awsrds.AuroraMysqlClusterEngineProps{
+			Version: awsrds.AuroraMysqlEngineVersion_VER_3_03_0(),
+		}),
+		Port:     3306,
+		Capacity: ServerlessV2,
+	}
+	AuroraMySQLServerlessV1 = &EngineVariant{
+		Engine:   awsrds.DatabaseClusterEngine_AURORA_MYSQL(),
+		Port:     3306,
+		Capacity: ServerlessV1,
+		// Serverless v1 is end of life, migrateToServerlessV2 replaces the cluster with the serverless v2 Acorn's
+		MigrationTarget: AuroraMySQLServerlessV2,
+	}
+	AuroraPostgresCluster = &EngineVariant{
+		Engine: awsrds.DatabaseClusterEngine_AuroraPostgres(&awsrds.AuroraPostgresClusterEngineProps{
+			Version: awsrds.AuroraPostgresEngineVersion_VER_15_3(),
+		}),
+		Port:     5432,
+		Capacity: Provisioned,
+	}
+)
@@ -6,7 +6,6 @@ import (
 	"github.com/aws/aws-cdk-go/awscdk/v2/awss3"
 	"github.com/aws/constructs-go/constructs/v10"
 	"github.com/aws/jsii-runtime-go"
-	"github.com/sirupsen/logrus"
 )
 
 type MyStackProps struct {
@@ -15,6 +14,21 @@ type MyStackProps struct {
 	Versioned  bool              `json:"versioned" yaml:"versioned"`
 	BucketName string            `json:"bucketName" yaml:"bucketName"`
 	UserTags   map[string]string `json:"tags" yaml:"tags"`
+	// Resource types the user tags are applied to
+	common.TagPropagationProps
+	// Read by the cdk-runner from the CDK_RUNNER_DELETE_PROTECTION and DRY_RUN env vars
+	DeletionProtection bool `json:"deletionProtection" yaml:"deletionProtection"`
+	DryRun             bool `json:"dryRun" yaml:"dryRun"`
+}
+
+func (props *MyStackProps) SetDefaults() {}
+
+func (props *MyStackProps) Validate() error {
+	return props.TagPropagationProps.Validate()
+}
+
+func (props *MyStackProps) GetTags() map[string]string {
+	return props.UserTags
 }
 
 func NewMyStack(scope constructs.Construct, id string, props *MyStackProps) awscdk.Stack {
@@ -48,22 +62,12 @@ func NewMyStack(scope constructs.Construct, id string, props *MyStackProps) awsc
 }
 
 func main() {
-	defer jsii.Close()
-
-	app := common.NewAcornTaggedApp(nil)
-
 	stackProps := &MyStackProps{
 		StackProps: *common.NewAWSCDKStackProps(),
 	}
 
-	// Read config from file
-	if err := common.NewConfig(stackProps); err != nil {
-		logrus.Fatal(err)
-	}
-
-	common.AppendScopedTags(app, stackProps.UserTags)
-
-	NewMyStack(app, "s3Stack", stackProps)
-
-	app.Synth(nil)
+	common.RunStack(stackProps, func(scope constructs.Construct, props *MyStackProps) error {
+		NewMyStack(scope, "s3Stack", props)
+		return nil
+	})
 }
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/acorn-io/services/aws/libs/common"
+	"github.com/acorn-io/services/aws/libs/common/stacktest"
+)
+
+func TestNewMyStackGolden(t *testing.T) {
+	tests := []struct {
+		name      string
+		overrides string
+	}{
+		{
+			name: "defaults",
+		},
+		{
+			name:      "public",
+			overrides: `{"bucketName": "PublicBucket", "versioned": false, "makePublic": true, "tags": {"team": "platform"}}`,
+		},
+		{
+			name:      "tags excluded",
+			overrides: `{"tags": {"team": "platform"}, "tagsExcludeResourceTypes": ["AWS::S3::Bucket"]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			props := &MyStackProps{StackProps: stacktest.StackProps()}
+			app := stacktest.NewAppWithConfig(t, stacktest.AcornfileConfig(t, "Acornfile", tt.overrides), props)
+			stacktest.AssertGolden(t, NewMyStack(app, "s3Stack", props))
+		})
+	}
+}
+
+func TestAcornfileArgs(t *testing.T) {
+	if err := common.ValidateAcornfileArgs(&MyStackProps{}, "Acornfile"); err != nil {
+		t.Error(err)
+	}
+}
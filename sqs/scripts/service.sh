@@ -12,6 +12,7 @@ fi
 url="$(jq -r '.[] | select(.OutputKey=="QueueURL")   |.OutputValue' outputs.json)"
 arn="$(jq -r '.[] | select(.OutputKey=="QueueARN")|.OutputValue' outputs.json )"
 name="$(jq -r '.[] | select(.OutputKey=="QueueName")|.OutputValue' outputs.json )"
+kms_key_arn="$(jq -r '.[] | select(.OutputKey=="KmsKeyARN")|.OutputValue' outputs.json )"
 
 proto="${url%%://*}"
 no_proto="${url#*://}"
@@ -19,6 +20,27 @@ address="${no_proto%%/*}"
 uri="${no_proto#*$address}"
 record_success
 
+# Messages of a queue encrypted with a customer managed key are sent and received with data keys of that key
+send_kms_rule=""
+receive_kms_rule=""
+if [ -n "${kms_key_arn}" ]; then
+    send_kms_rule=", {
+            apiGroup: \"aws.acorn.io\"
+            verbs: [
+                \"kms:Decrypt\",
+                \"kms:GenerateDataKey\",
+            ]
+            resources: [\"${kms_key_arn}\"]
+        }"
+    receive_kms_rule=", {
+            apiGroup: \"aws.acorn.io\"
+            verbs: [
+                \"kms:Decrypt\",
+            ]
+            resources: [\"${kms_key_arn}\"]
+        }"
+fi
+
 
 cat > /run/secrets/output <<EOF
 services: {
@@ -31,7 +53,7 @@ services: {
 			    "sqs:*",
 		    ]
 		    resources: ["${arn}"]
-        }]
+        }${send_kms_rule}]
         data: {
             arn: "${arn}"
             proto: "${proto}"
@@ -49,7 +71,7 @@ services: {
                 "sqs:SendMessage",
             ]
             resources: ["${arn}"]
-        }]
+        }${send_kms_rule}]
         data: {
             arn: "${arn}"
             proto: "${proto}"
@@ -69,7 +91,7 @@ services: {
                 "sqs:GetQueueUrl",
             ]
             resources: ["${arn}"]
-        }]
+        }${receive_kms_rule}]
         data: {
             arn: "${arn}"
             proto: "${proto}"
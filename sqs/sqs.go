@@ -1,12 +1,11 @@
 package main
 
 import (
-	"os"
-	"strings"
-
 	"github.com/acorn-io/services/aws/libs/common"
+	"github.com/acorn-io/services/aws/libs/common/naming"
 	"github.com/aws/aws-cdk-go/awscdk/v2"
 	"github.com/aws/aws-cdk-go/awscdk/v2/awsiam"
+	"github.com/aws/aws-cdk-go/awscdk/v2/awskms"
 	"github.com/aws/aws-cdk-go/awscdk/v2/awssqs"
 	"github.com/aws/constructs-go/constructs/v10"
 	"github.com/aws/jsii-runtime-go"
@@ -18,6 +17,7 @@ type MyStackProps struct {
 	AccessPolicies            []policyStatement `json:"accessPolicies,omitempty"`
 	ContentBasedDeduplication bool              `json:"contentBasedDeduplication,omitempty"`
 	DataKeyReuse              int               `json:"dataKeyReuse,omitempty"`
+	EncryptionMasterKey       string            `json:"encryptionMasterKey,omitempty"`
 	ExternalID                string
 	Fifo                      bool              `json:"fifo,omitempty"`
 	MaxReceiveCount           int               `json:"maxReceiveCount,omitempty"`
@@ -90,27 +90,32 @@ func (myStp *MyStackProps) addPoliciesToQueue(queue awssqs.Queue) {
 	}
 }
 
-func NewStackProps() (*MyStackProps, error) {
-	stackProps := &MyStackProps{
-		StackProps: *common.NewAWSCDKStackProps(),
-	}
-
-	if err := common.NewConfig(stackProps); err != nil {
-		return nil, err
+func (myStp *MyStackProps) SetDefaults() {
+	if myStp.VisibilityTimeout == 0 {
+		myStp.VisibilityTimeout = 30
 	}
 
-	if stackProps.VisibilityTimeout == 0 {
-		stackProps.VisibilityTimeout = 30
+	// Without a name, CloudFormation generates one that fits, including the .fifo suffix
+	if myStp.QueueName != "" {
+		limits := naming.SQSQueue
+		if myStp.Fifo {
+			limits = naming.SQSFIFOQueue
+		}
+		if name := limits.Fit(myStp.QueueName); name != myStp.QueueName {
+			logrus.Infof("Changing queue name %s to %s to meet the SQS naming requirements", myStp.QueueName, name)
+			myStp.QueueName = name
+		}
 	}
 
-	if stackProps.Fifo && !strings.Contains(stackProps.QueueName, ".fifo") {
-		logrus.Infof("Adding required .fifo suffix to queue name: %s", stackProps.QueueName)
-		stackProps.QueueName = stackProps.QueueName + ".fifo"
-	}
+	myStp.ExternalID = naming.AcornFromEnv().ExternalID
+}
 
-	stackProps.ExternalID = os.Getenv("ACORN_EXTERNAL_ID")
+func (myStp *MyStackProps) Validate() error {
+	return nil
+}
 
-	return stackProps, nil
+func (myStp *MyStackProps) GetTags() map[string]string {
+	return myStp.UserTags
 }
 
 func NewSQSStack(scope constructs.Construct, id string, props *MyStackProps) awscdk.Stack {
@@ -135,6 +140,11 @@ func NewSQSStack(scope constructs.Construct, id string, props *MyStackProps) aws
 		queueProps.QueueName = jsii.String(props.QueueName)
 	}
 
+	if props.EncryptionMasterKey != "" {
+		queueProps.Encryption = awssqs.QueueEncryption_KMS
+		queueProps.EncryptionMasterKey = awskms.Key_FromKeyArn(stack, jsii.String("sqsQueueKey"), jsii.String(props.EncryptionMasterKey))
+	}
+
 	if props.MaxReceiveCount != 0 {
 		dlq := awssqs.NewQueue(stack, jsii.String("sqsQueueDlq"), &awssqs.QueueProps{
 			Fifo: jsii.Bool(props.Fifo),
@@ -160,20 +170,22 @@ func NewSQSStack(scope constructs.Construct, id string, props *MyStackProps) aws
 		Value: queue.QueueName(),
 	})
 
+	if props.EncryptionMasterKey != "" {
+		awscdk.NewCfnOutput(stack, jsii.String("KmsKeyARN"), &awscdk.CfnOutputProps{
+			Value: jsii.String(props.EncryptionMasterKey),
+		})
+	}
+
 	return stack
 }
 
 func main() {
-	defer jsii.Close()
-
-	app := common.NewAcornTaggedApp(nil)
-	stackProps, err := NewStackProps()
-	if err != nil {
-		logrus.Fatal(err)
+	stackProps := &MyStackProps{
+		StackProps: *common.NewAWSCDKStackProps(),
 	}
 
-	common.AppendScopedTags(app, stackProps.UserTags)
-	NewSQSStack(app, "sqsStack", stackProps)
-
-	app.Synth(nil)
+	common.RunStack(stackProps, func(scope constructs.Construct, props *MyStackProps) error {
+		NewSQSStack(scope, "sqsStack", props)
+		return nil
+	})
 }
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/acorn-io/services/aws/libs/common"
+	"github.com/acorn-io/services/aws/libs/common/stacktest"
+	"github.com/aws/aws-cdk-go/awscdk/v2/assertions"
+	"github.com/aws/jsii-runtime-go"
+)
+
+func TestNewSQSStackGolden(t *testing.T) {
+	tests := []struct {
+		name      string
+		overrides string
+	}{
+		{
+			name: "defaults",
+		},
+		{
+			name:      "fifo",
+			overrides: `{"queueName": "orders", "fifo": true, "contentBasedDeduplication": true, "maxReceiveCount": 3, "encryptionMasterKey": "arn:aws:kms:us-east-1:123456789012:key/0123abcd-01ab-23cd-45ef-0123456789ab", "tags": {"team": "platform"}}`,
+		},
+		{
+			name:      "access policies",
+			overrides: `{"accessPolicies": [{"effect": "Allow", "actions": ["sqs:SendMessage"], "principals": [{"principalType": "service", "identity": "sns.amazonaws.com"}]}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			props := &MyStackProps{StackProps: stacktest.StackProps()}
+			app := stacktest.NewAppWithConfig(t, stacktest.AcornfileConfig(t, "Acornfile", tt.overrides), props)
+			stacktest.AssertGolden(t, NewSQSStack(app, "sqsStack", props))
+		})
+	}
+}
+
+func TestEncryptionMasterKey(t *testing.T) {
+	key := "arn:aws:kms:us-east-1:123456789012:key/0123abcd-01ab-23cd-45ef-0123456789ab"
+	tests := []struct {
+		name           string
+		overrides      string
+		kmsMasterKeyID any
+		keyOutputs     int
+	}{
+		{
+			name:           "default",
+			kmsMasterKeyID: assertions.Match_Absent(),
+		},
+		{
+			name:           "key",
+			overrides:      `{"encryptionMasterKey": "` + key + `"}`,
+			kmsMasterKeyID: key,
+			keyOutputs:     1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			props := &MyStackProps{StackProps: stacktest.StackProps()}
+			app := stacktest.NewAppWithConfig(t, stacktest.AcornfileConfig(t, "Acornfile", tt.overrides), props)
+			template := assertions.Template_FromStack(NewSQSStack(app, "sqsStack", props), nil)
+			template.HasResourceProperties(jsii.String("AWS::SQS::Queue"), map[string]any{
+				"VisibilityTimeout": 30,
+				"KmsMasterKeyId":    tt.kmsMasterKeyID,
+			})
+			// The service script grants the consumers use of the key in this output
+			outputs := template.FindOutputs(jsii.String("KmsKeyARN"), map[string]any{"Value": key})
+			if len(*outputs) != tt.keyOutputs {
+				t.Errorf("expected %d KmsKeyARN outputs, got %d", tt.keyOutputs, len(*outputs))
+			}
+		})
+	}
+}
+
+func TestAcornfileArgs(t *testing.T) {
+	if err := common.ValidateAcornfileArgs(&MyStackProps{}, "Acornfile"); err != nil {
+		t.Error(err)
+	}
+}
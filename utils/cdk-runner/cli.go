@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/acorn-io/aws/utils/cdk-runner/pkg/assets"
+	"github.com/acorn-io/aws/utils/cdk-runner/pkg/aws/cloudformation"
+	"github.com/acorn-io/aws/utils/cdk-runner/pkg/diff"
+	"github.com/acorn-io/aws/utils/cdk-runner/pkg/hooks"
+	"github.com/acorn-io/aws/utils/cdk-runner/pkg/policy"
+	"github.com/acorn-io/aws/utils/cdk-runner/pkg/timeouts"
+)
+
+const usage = `Usage: cdk-runner [command] [flags]
+
+Without a command the runner handles the event of the Acorn job it runs in, set by ACORN_EVENT.
+
+Commands:
+  deploy   Create or update the stack, and wait for it to finish
+  delete   Delete the stack
+  diff     Create the change set of the stack and print the diff of the templates without applying it
+  outputs  Print the outputs of the stack as JSON
+  synth    Synthesize the CDK app to a template file
+
+Run cdk-runner <command> -h for the flags of a command.
+`
+
+// runCommand runs a command of the command line
+func runCommand(command string, args []string, t timeouts.Config) error {
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+
+	opts := options{Event: command, Timeouts: t}
+	flags.StringVar(&opts.StackName, "stack", os.Getenv("ACORN_EXTERNAL_ID"), "name of the CloudFormation stack")
+	workDir := flags.String("work-dir", ".", "directory of the hooks and policies, and of the current template and change set files")
+	endpoint := flags.String("endpoint-url", os.Getenv(cloudformation.EndpointURLEnvKey), "CloudFormation endpoint to use instead of the AWS one, like a local emulator")
+	output := SynthOutputFile
+
+	switch command {
+	case "deploy", "diff":
+		flags.StringVar(&opts.TemplateFile, "template", "", "template to deploy, the CDK app is synthesized when it is not set")
+		flags.BoolVar(&opts.Acorn, "acorn", false, "report the progress to the Acorn and render its services, like the Acorn job")
+		flags.StringVar(&opts.DiffFormat, "format", os.Getenv(cloudformation.DiffFormatEnvKey), "format of the diff printed on dry runs, text or markdown")
+		flags.StringVar(&opts.TemplateBucket, "template-bucket", os.Getenv(cloudformation.TemplateBucketEnvKey), "bucket to stage templates over the inline size limit in, a bucket of the account is created when it is not set")
+		if command == "deploy" {
+			flags.BoolVar(&opts.DryRun, "dry-run", cloudformation.IsDryRun(), "create the change set without applying it")
+			flags.StringVar(&opts.AssetsDir, "cdk-out", "", "cloud assembly directory to publish the assets of, cdk.out when the CDK app is synthesized")
+			flags.StringVar(&opts.AssetsRegistry, "assets-registry", os.Getenv(assets.RegistryEnvKey), "registry to push the image assets to instead of ECR, like a local registry")
+		} else {
+			opts.DryRun = true
+		}
+		opts.Timeouts.RegisterFlags(flags)
+	case "delete":
+		flags.BoolVar(&opts.Acorn, "acorn", false, "report the progress to the Acorn, like the Acorn job")
+		opts.Timeouts.RegisterFlags(flags)
+	case "outputs":
+	case "synth":
+		flags.StringVar(&output, "output", SynthOutputFile, "template file to write")
+	case "help", "-h", "-help", "--help":
+		fmt.Print(usage)
+		return nil
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		return fmt.Errorf("unknown command %q", command)
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments %v", flags.Args())
+	}
+	if err := opts.Timeouts.Validate(); err != nil {
+		return err
+	}
+	hooks.Timeout = opts.Timeouts.Hook
+
+	if opts.DiffFormat != "" && opts.DiffFormat != diff.FormatText && opts.DiffFormat != diff.FormatMarkdown {
+		return fmt.Errorf("unknown diff format %q, use %s or %s", opts.DiffFormat, diff.FormatText, diff.FormatMarkdown)
+	}
+	if opts.StackName == "" && command != "synth" {
+		return fmt.Errorf("the --stack flag is required")
+	}
+
+	if *endpoint != "" {
+		if err := os.Setenv(cloudformation.EndpointURLEnvKey, *endpoint); err != nil {
+			return err
+		}
+	}
+	cloudformation.CurrentTemplateFile = filepath.Join(*workDir, "current-template.yaml")
+	cloudformation.ChangeSetFile = filepath.Join(*workDir, "change-set.json")
+	hooks.Dir = filepath.Join(*workDir, "hooks")
+	policy.DefaultDir = filepath.Join(*workDir, "policies")
+
+	switch command {
+	case "outputs":
+		return printOutputs(opts.StackName)
+	case "synth":
+		return synth(opts.hookContext(), output)
+	default:
+		return runAndReport(opts)
+	}
+}
+
+func printOutputs(stackName string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	client, err := cloudformation.NewClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	outputs, err := cloudformation.Outputs(client, stackName)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(outputs, "", "    ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Println(string(data))
+	return err
+}
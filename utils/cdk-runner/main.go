@@ -9,56 +9,185 @@ import (
 	"time"
 
 	"github.com/acorn-io/aws/utils/cdk-runner/pkg/acorn"
+	"github.com/acorn-io/aws/utils/cdk-runner/pkg/assets"
 	"github.com/acorn-io/aws/utils/cdk-runner/pkg/aws/cloudformation"
 	"github.com/acorn-io/aws/utils/cdk-runner/pkg/cdk"
+	"github.com/acorn-io/aws/utils/cdk-runner/pkg/hooks"
+	"github.com/acorn-io/aws/utils/cdk-runner/pkg/report"
+	"github.com/acorn-io/aws/utils/cdk-runner/pkg/timeouts"
 	"github.com/acorn-io/aws/utils/cdk-runner/pkg/utils"
 	_ "github.com/acorn-io/baaah/pkg/logrus"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
 	"github.com/sirupsen/logrus"
 )
 
 const (
 	CloudformationOutputFile = "outputs.json"
+	DeploymentReportFile     = "report.json"
 	AcornRenderExecutable    = "./scripts/service.sh"
+	SynthOutputFile          = "cfn.yaml"
+	// CloudAssemblyDir is where cdk synth writes the cloud assembly with the asset manifests
+	CloudAssemblyDir = "cdk.out"
 )
 
-func applyCfnTemplateFile(inputFile, stackName string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Minute)
-	defer cancel()
+// options of a run, from the Acorn environment or the command line
+type options struct {
+	StackName string
+	// Event is the Acorn event, create, update or delete, or the command run from the command line
+	Event string
+	// TemplateFile is the template to deploy, the CDK app is synthesized to cfn.yaml when it is empty
+	TemplateFile string
+	DryRun       bool
+	// DiffFormat is the format of the diff printed on dry runs, text or markdown
+	DiffFormat string
+	// TemplateBucket is the bucket templates over the inline size limit are staged in
+	TemplateBucket string
+	// AssetsDir is the cloud assembly whose assets are published before the change set, none when it is empty
+	AssetsDir string
+	// AssetsRegistry replaces the ECR registry of the image assets
+	AssetsRegistry string
+	// Acorn reports the progress to the Acorn and renders its services, as the runner does in an Acorn job
+	Acorn    bool
+	Timeouts timeouts.Config
+}
 
+func (o options) hookContext() hooks.Context {
+	return hooks.Context{StackName: o.StackName, Event: o.Event, DryRun: o.DryRun}
+}
+
+// newClient returns a client recording the run, and starts watching the stack for the Acorn
+func newClient(ctx context.Context, opts options, hctx hooks.Context, rep *report.Report) (*cloudformation.Client, error) {
 	client, err := cloudformation.NewClient(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	client.Report = rep
+	client.Hooks = hctx
+	client.DryRun = opts.DryRun
+	client.Timeouts = opts.Timeouts
+	if opts.DiffFormat != "" {
+		client.DiffFormat = opts.DiffFormat
+	}
+	if opts.TemplateBucket != "" {
+		client.TemplateBucket = opts.TemplateBucket
+	}
+
+	if opts.Acorn {
+		go acorn.StartEventWatcher(ctx, opts.StackName, opts.Timeouts.WatcherPoll)
+	}
+	return client, nil
+}
 
-	go acorn.StartEventWatcher(ctx, stackName)
+func applyCfnTemplateFile(inputFile string, opts options, hctx hooks.Context, rep *report.Report) error {
+	stackName := opts.StackName
+
+	ctx, cancel := context.WithTimeout(context.Background(), opts.Timeouts.Operation)
+	defer cancel()
+
+	client, err := newClient(ctx, opts, hctx, rep)
+	if err != nil {
+		return err
+	}
 
 	templateBytes, err := os.ReadFile(inputFile)
 	if err != nil {
 		return err
 	}
 
-	if err := cloudformation.DeployStack(client, stackName, string(templateBytes)); err != nil {
+	if err := publishAssets(client, opts); err != nil {
+		return err
+	}
+
+	err = cloudformation.DeployStack(client, stackName, string(templateBytes))
+	cloudformation.RecordStack(client, stackName)
+	if err != nil {
 		return err
 	}
 
 	if err := cloudformation.WriteOutputsToFile(client, stackName, CloudformationOutputFile); err != nil {
 		return err
 	}
+
+	if !opts.DryRun {
+		if hctx.Outputs, err = cloudformation.Outputs(client, stackName); err != nil {
+			return err
+		}
+		if err := hooks.Run(hooks.PostApply, hctx); err != nil {
+			return err
+		}
+	}
+
+	if !opts.Acorn {
+		return nil
+	}
 	return runServiceAcornRenderExec(AcornRenderExecutable)
 }
 
-func deleteStack(stackName string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Minute)
+// publishAssets publishes the assets of the cloud assembly the template was synthesized to. Dry runs only create the
+// change set, which does not need them.
+func publishAssets(client *cloudformation.Client, opts options) error {
+	if opts.AssetsDir == "" || opts.DryRun {
+		return nil
+	}
+	publisher := assets.NewPublisher(client.Ctx, client.Config)
+	publisher.Report = client.Report
+	if opts.AssetsRegistry != "" {
+		publisher.Registry = opts.AssetsRegistry
+	}
+	return publisher.Publish(opts.AssetsDir)
+}
+
+func deleteStack(opts options, hctx hooks.Context, rep *report.Report) error {
+	stackName := opts.StackName
+
+	ctx, cancel := context.WithTimeout(context.Background(), opts.Timeouts.Operation)
 	defer cancel()
 
-	client, err := cloudformation.NewClient(ctx)
+	client, err := newClient(ctx, opts, hctx, rep)
 	if err != nil {
 		return err
 	}
 
-	go acorn.StartEventWatcher(ctx, stackName)
+	// A stack that does not exist is deleted, any other error, like a denied permission, fails the run
+	stack, err := cloudformation.GetStack(client, stackName)
+	if err != nil && !cloudformation.IsNotExist(err) {
+		return err
+	} else if !stack.Exists {
+		return nil
+	}
+
+	// The outputs are gone once the stack is deleted, keep them around for the post-delete hook
+	if err := cloudformation.WriteOutputsToFile(client, stackName, CloudformationOutputFile); err != nil {
+		return err
+	}
+	cloudformation.RecordStack(client, stackName)
+
+	if hctx.Outputs, err = cloudformation.Outputs(client, stackName); err != nil {
+		return err
+	}
+	if err := hooks.Run(hooks.PreDelete, hctx); err != nil {
+		return err
+	}
+
+	if err := cloudformation.Delete(client, stackName); err != nil {
+		cloudformation.RecordStack(client, stackName)
+		return err
+	}
+	rep.SetStackStatus(types.StackStatusDeleteComplete)
 
-	return cloudformation.Delete(client, stackName)
+	return hooks.Run(hooks.PostDelete, hctx)
+}
+
+// synth synthesizes the CDK app to the output file, running the synth hooks around it
+func synth(hctx hooks.Context, outputFile string) error {
+	if err := hooks.Run(hooks.PreSynth, hctx); err != nil {
+		return err
+	}
+	if err := cdk.GenerateTemplateFile(outputFile); err != nil {
+		return err
+	}
+	hctx.NewTemplate = outputFile
+	return hooks.Run(hooks.PostSynth, hctx)
 }
 
 func runServiceAcornRenderExec(executable string) error {
@@ -73,8 +202,8 @@ func runServiceAcornRenderExec(executable string) error {
 
 }
 
-func waitForStackToFinishTransition(stackName string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Minute)
+func waitForStackToFinishTransition(stackName string, t timeouts.Config) error {
+	ctx, cancel := context.WithTimeout(context.Background(), t.Operation)
 	defer cancel()
 	client, err := cloudformation.NewClient(ctx)
 	if err != nil {
@@ -84,15 +213,15 @@ func waitForStackToFinishTransition(stackName string) error {
 	for {
 		select {
 		case <-ctx.Done():
-			return fmt.Errorf("stack %s did not finish transitioning after 60 minutes", stackName)
+			return fmt.Errorf("stack %s did not finish transitioning after %s", stackName, t.Operation)
 		default:
-			t, status, err := cloudformation.StackOperationInProgress(client, stackName)
+			inProgress, status, err := cloudformation.StackOperationInProgress(client, stackName)
 			if err != nil {
 				return err
 			}
-			if t {
+			if inProgress {
 				logrus.Infof("Waiting: stack %s is transitioning with status %s", stackName, status)
-				time.Sleep(time.Second * 30)
+				time.Sleep(t.TransitionPoll)
 				continue
 			}
 			return nil
@@ -100,27 +229,76 @@ func waitForStackToFinishTransition(stackName string) error {
 	}
 }
 
-func main() {
-	stackName := os.Getenv("ACORN_EXTERNAL_ID")
-	event := os.Getenv("ACORN_EVENT")
-
-	if err := waitForStackToFinishTransition(stackName); err != nil {
-		logrus.Fatal(err)
+func run(opts options, rep *report.Report) error {
+	if err := waitForStackToFinishTransition(opts.StackName, opts.Timeouts); err != nil {
+		return err
 	}
 
-	if event == "create" || event == "update" {
-		if err := cdk.GenerateTemplateFile("cfn.yaml"); err != nil {
-			logrus.Fatal(err)
+	hctx := opts.hookContext()
+	switch opts.Event {
+	case "create", "update", "deploy", "diff":
+		template := opts.TemplateFile
+		if template == "" {
+			template = SynthOutputFile
+			if err := synth(hctx, template); err != nil {
+				return err
+			}
+			if opts.AssetsDir == "" {
+				opts.AssetsDir = CloudAssemblyDir
+			}
+		}
+		hctx.NewTemplate = template
+
+		if err := applyCfnTemplateFile(template, opts, hctx, rep); err != nil {
+			return err
 		}
+	case "delete":
+		if err := deleteStack(opts, hctx, rep); err != nil {
+			return utils.WriteToTermLogAndError([]byte(err.Error()), err)
+		}
+	}
+	return nil
+}
+
+// runAndReport runs the deployment or deletion and writes the report, also when the run fails
+func runAndReport(opts options) error {
+	rep := report.New(opts.StackName, opts.Event, opts.DryRun)
+	err := run(opts, rep)
 
-		if err := applyCfnTemplateFile("cfn.yaml", stackName); err != nil {
-			logrus.Fatal(err)
+	if err != nil {
+		hctx := opts.hookContext()
+		hctx.Error = err.Error()
+		if err := hooks.Run(hooks.OnFailure, hctx); err != nil {
+			logrus.Warnf("on-failure hook failed: %v", err)
 		}
 	}
 
-	if event == "delete" {
-		if err := deleteStack(stackName); err != nil {
-			logrus.Fatal(utils.WriteToTermLogAndError([]byte(err.Error()), err))
+	rep.Finish(err)
+	if err := rep.WriteFile(DeploymentReportFile); err != nil {
+		logrus.Warnf("failed to write the deployment report: %v", err)
+	}
+	return err
+}
+
+func main() {
+	t, err := timeouts.FromEnv()
+	if err == nil {
+		hooks.Timeout = t.Hook
+		if len(os.Args) > 1 {
+			err = runCommand(os.Args[1], os.Args[2:], t)
+		} else {
+			// Without a command the runner runs as an Acorn job, handling the event of the Acorn
+			err = runAndReport(options{
+				StackName: os.Getenv("ACORN_EXTERNAL_ID"),
+				Event:     os.Getenv("ACORN_EVENT"),
+				DryRun:    cloudformation.IsDryRun(),
+				Acorn:     true,
+				Timeouts:  t,
+			})
 		}
 	}
+
+	if err != nil {
+		logrus.Fatal(err)
+	}
 }
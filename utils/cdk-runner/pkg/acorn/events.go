@@ -32,6 +32,8 @@ type StackWatcher struct {
 	CfnClient *cloudformation.Client
 	KClient   k8sClient.WithWatch
 	Stack     *Stack
+	// PollInterval is how often the stack is checked
+	PollInterval time.Duration
 }
 
 type Stack struct {
@@ -49,7 +51,8 @@ type Counts struct {
 	DeletedCount    int
 }
 
-func StartEventWatcher(ctx context.Context, stackName string) {
+// StartEventWatcher reports the progress of the stack to the Acorn, polling the stack at the interval
+func StartEventWatcher(ctx context.Context, stackName string, pollInterval time.Duration) {
 	client, err := acrnCfnClient.NewClient(ctx)
 	if err != nil {
 		logrus.Fatal(err)
@@ -72,9 +75,10 @@ func StartEventWatcher(ctx context.Context, stackName string) {
 			CurrentCounts: Counts{},
 			PrevCounts:    Counts{},
 		},
-		Context:   ctx,
-		CfnClient: awsClient,
-		KClient:   k8sClient,
+		Context:      ctx,
+		CfnClient:    awsClient,
+		KClient:      k8sClient,
+		PollInterval: pollInterval,
 	}
 
 	if sw.Stack.Name == "" {
@@ -137,7 +141,7 @@ func (sw *StackWatcher) watchStack() error {
 			}
 
 			sw.emit()
-			time.Sleep(30 * time.Second)
+			time.Sleep(sw.PollInterval)
 		}
 	}
 }
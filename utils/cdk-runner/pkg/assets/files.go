@@ -0,0 +1,141 @@
+package assets
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// zipModTime is the modification time of the zipped files, so the same directory always zips to the same archive
+var zipModTime = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func (p *Publisher) publishFile(m *Manifest, id string, asset FileAsset) error {
+	if len(asset.Source.Executable) > 0 {
+		return fmt.Errorf("assets built by an executable are not supported")
+	}
+
+	path := filepath.Join(m.dir, asset.Source.Path)
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	if info.IsDir() {
+		if asset.Source.Packaging != PackagingZip {
+			return fmt.Errorf("%s is a directory, only zip packaging uploads directories", path)
+		}
+		archive, err := os.CreateTemp("", "cdk-asset-*.zip")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(archive.Name())
+		defer archive.Close()
+		if err := zipDir(path, archive); err != nil {
+			return err
+		}
+		path = archive.Name()
+	}
+
+	for _, key := range sortedKeys(asset.Destinations) {
+		if err := p.uploadFile(id, path, asset.Destinations[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *Publisher) uploadFile(id, path string, dest FileDestination) error {
+	bucket, err := p.resolve(dest.BucketName, dest.Region)
+	if err != nil {
+		return err
+	}
+	key, err := p.resolve(dest.ObjectKey, dest.Region)
+	if err != nil {
+		return err
+	}
+	destination := fmt.Sprintf("s3://%s/%s", bucket, key)
+	client := p.s3Client(p.config(dest.Region, dest.AssumeRoleArn, dest.AssumeRoleExternalID))
+
+	// The object keys are hashes of the contents, an object that exists has the same contents
+	_, err = client.HeadObject(p.Ctx, &s3.HeadObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)})
+	var notFound *s3types.NotFound
+	if err == nil {
+		p.record(id, "file", destination, false)
+		return nil
+	} else if !errors.As(err, &notFound) {
+		return err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := client.PutObject(p.Ctx, &s3.PutObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+		Body:   f,
+	}); err != nil {
+		return fmt.Errorf("failed to upload to %s: %w", destination, err)
+	}
+	p.record(id, "file", destination, true)
+	return nil
+}
+
+// zipDir writes the files of the directory to the archive, in lexical order and keeping their permissions, like
+// the executable bit of Lambda handlers
+func zipDir(dir string, w io.Writer) error {
+	zw := zip.NewWriter(w)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || path == dir {
+			return err
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		header.Modified = zipModTime
+		if info.IsDir() {
+			header.Name += "/"
+			_, err := zw.CreateHeader(header)
+			return err
+		}
+		header.Method = zip.Deflate
+
+		fw, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(fw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return zw.Close()
+}
@@ -0,0 +1,94 @@
+package assets
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestZipDir(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]os.FileMode
+		want  []string
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name: "files in lexical order",
+			files: map[string]os.FileMode{
+				"index.js":         0644,
+				"lib/util.js":      0644,
+				"bootstrap":        0755,
+				"lib/nested/a.txt": 0600,
+			},
+			want: []string{"bootstrap 755", "index.js 644", "lib/ 755", "lib/nested/ 755", "lib/nested/a.txt 600", "lib/util.js 644"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, mode := range tt.files {
+				path := filepath.Join(dir, name)
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(name), mode); err != nil {
+					t.Fatal(err)
+				}
+				// Ignore the umask
+				if err := os.Chmod(path, mode); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var first, second bytes.Buffer
+			if err := zipDir(dir, &first); err != nil {
+				t.Fatal(err)
+			}
+			if err := zipDir(dir, &second); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(first.Bytes(), second.Bytes()) {
+				t.Error("expected the same directory to zip to the same archive")
+			}
+
+			r, err := zip.NewReader(bytes.NewReader(first.Bytes()), int64(first.Len()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, f := range r.File {
+				got = append(got, f.Name+" "+fmt.Sprintf("%o", f.Mode().Perm()))
+				if !f.Modified.Equal(zipModTime) {
+					t.Errorf("expected %s to be modified at %s, got %s", f.Name, zipModTime, f.Modified)
+				}
+				if f.Mode().IsDir() {
+					continue
+				}
+				rc, err := f.Open()
+				if err != nil {
+					t.Fatal(err)
+				}
+				content, err := io.ReadAll(rc)
+				rc.Close()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(content) != f.Name {
+					t.Errorf("expected %s to hold its name, got %q", f.Name, content)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
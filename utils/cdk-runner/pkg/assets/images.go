@@ -0,0 +1,160 @@
+package assets
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ecr"
+	ecrtypes "github.com/aws/aws-sdk-go-v2/service/ecr/types"
+	"github.com/sirupsen/logrus"
+)
+
+func (p *Publisher) publishImage(m *Manifest, id string, asset ImageAsset) error {
+	if len(asset.Source.Executable) > 0 {
+		return fmt.Errorf("images built by an executable are not supported")
+	}
+
+	// The image is built once, on the first destination it is missing from
+	localTag := "cdkasset-" + strings.ToLower(id)
+	built := false
+
+	for _, key := range sortedKeys(asset.Destinations) {
+		dest := asset.Destinations[key]
+		repository, err := p.resolve(dest.RepositoryName, dest.Region)
+		if err != nil {
+			return err
+		}
+		tag, err := p.resolve(dest.ImageTag, dest.Region)
+		if err != nil {
+			return err
+		}
+
+		var registry string
+		if p.Registry != "" {
+			registry = p.Registry
+		} else {
+			client := ecr.NewFromConfig(p.config(dest.Region, dest.AssumeRoleArn, dest.AssumeRoleExternalID))
+			exists, err := p.imageExists(client, repository, tag)
+			if err != nil {
+				return err
+			}
+			if registry, err = p.login(client); err != nil {
+				return err
+			}
+			if exists {
+				p.record(id, "image", fmt.Sprintf("%s/%s:%s", registry, repository, tag), false)
+				continue
+			}
+		}
+		image := fmt.Sprintf("%s/%s:%s", registry, repository, tag)
+
+		if !built {
+			if err := p.build(filepath.Join(m.dir, asset.Source.Directory), localTag, asset.Source); err != nil {
+				return err
+			}
+			built = true
+		}
+		if err := p.docker(nil, "tag", localTag, image); err != nil {
+			return err
+		}
+		if err := p.docker(nil, "push", image); err != nil {
+			return err
+		}
+		p.record(id, "image", image, true)
+	}
+	return nil
+}
+
+// imageExists returns whether the repository has the tag. The tags are hashes of the image sources, so an image
+// with the tag was built from the same sources.
+func (p *Publisher) imageExists(client *ecr.Client, repository, tag string) (bool, error) {
+	_, err := client.DescribeImages(p.Ctx, &ecr.DescribeImagesInput{
+		RepositoryName: aws.String(repository),
+		ImageIds:       []ecrtypes.ImageIdentifier{{ImageTag: aws.String(tag)}},
+	})
+	var notFound *ecrtypes.ImageNotFoundException
+	var noRepository *ecrtypes.RepositoryNotFoundException
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.As(err, &notFound):
+		return false, nil
+	case errors.As(err, &noRepository):
+		return false, fmt.Errorf("ECR repository %s does not exist, create it or bootstrap the account: %w", repository, err)
+	}
+	return false, err
+}
+
+// login logs Docker in to the ECR registry of the client and returns the registry
+func (p *Publisher) login(client *ecr.Client) (string, error) {
+	auth, err := client.GetAuthorizationToken(p.Ctx, &ecr.GetAuthorizationTokenInput{})
+	if err != nil {
+		return "", err
+	}
+	if len(auth.AuthorizationData) == 0 {
+		return "", fmt.Errorf("ECR returned no authorization data")
+	}
+
+	data := auth.AuthorizationData[0]
+	token, err := base64.StdEncoding.DecodeString(aws.ToString(data.AuthorizationToken))
+	if err != nil {
+		return "", err
+	}
+	username, password, ok := strings.Cut(string(token), ":")
+	if !ok {
+		return "", fmt.Errorf("ECR returned an invalid authorization token")
+	}
+
+	registry := strings.TrimPrefix(aws.ToString(data.ProxyEndpoint), "https://")
+	if err := p.docker(strings.NewReader(password), "login", "--username", username, "--password-stdin", registry); err != nil {
+		return "", err
+	}
+	return registry, nil
+}
+
+func (p *Publisher) build(dir, tag string, source ImageSource) error {
+	args := []string{"build", "--tag", tag}
+	if source.DockerFile != "" {
+		// The Dockerfile is relative to the build directory
+		args = append(args, "--file", filepath.Join(dir, source.DockerFile))
+	}
+	if source.DockerBuildTarget != "" {
+		args = append(args, "--target", source.DockerBuildTarget)
+	}
+	for _, name := range sortedKeys(source.DockerBuildArgs) {
+		args = append(args, "--build-arg", fmt.Sprintf("%s=%s", name, source.DockerBuildArgs[name]))
+	}
+	if source.NetworkMode != "" {
+		args = append(args, "--network", source.NetworkMode)
+	}
+	if source.Platform != "" {
+		args = append(args, "--platform", source.Platform)
+	}
+	return p.docker(nil, append(args, dir)...)
+}
+
+// docker runs the Docker command, logging its output
+func (p *Publisher) docker(stdin io.Reader, args ...string) error {
+	log := logrus.WithField("docker", args[0])
+	stdout := log.WriterLevel(logrus.InfoLevel)
+	defer stdout.Close()
+
+	// Keep stderr around for the error
+	var stderr bytes.Buffer
+
+	cmd := exec.CommandContext(p.Ctx, p.Docker, args...)
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = io.MultiWriter(stdout, &stderr)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s %s failed: %w: %s", p.Docker, args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package assets
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+const (
+	PackagingFile = "file"
+	PackagingZip  = "zip"
+)
+
+// Manifest is an asset manifest of a cloud assembly, the <stack>.assets.json files cdk synth writes to cdk.out
+type Manifest struct {
+	Version      string                `json:"version"`
+	Files        map[string]FileAsset  `json:"files"`
+	DockerImages map[string]ImageAsset `json:"dockerImages"`
+
+	// dir is the directory the source paths are relative to
+	dir string
+}
+
+// FileAsset is a file or directory published to S3
+type FileAsset struct {
+	Source       FileSource                 `json:"source"`
+	Destinations map[string]FileDestination `json:"destinations"`
+}
+
+type FileSource struct {
+	Path string `json:"path"`
+	// Packaging is file to upload the file as is, or zip to zip the directory first
+	Packaging string `json:"packaging"`
+	// Executable produces the asset with a command, which the runner does not support
+	Executable []string `json:"executable"`
+}
+
+type FileDestination struct {
+	Region               string `json:"region"`
+	AssumeRoleArn        string `json:"assumeRoleArn"`
+	AssumeRoleExternalID string `json:"assumeRoleExternalId"`
+	BucketName           string `json:"bucketName"`
+	ObjectKey            string `json:"objectKey"`
+}
+
+// ImageAsset is a Docker image built and pushed to ECR
+type ImageAsset struct {
+	Source       ImageSource                 `json:"source"`
+	Destinations map[string]ImageDestination `json:"destinations"`
+}
+
+type ImageSource struct {
+	Directory         string            `json:"directory"`
+	DockerFile        string            `json:"dockerFile"`
+	DockerBuildTarget string            `json:"dockerBuildTarget"`
+	DockerBuildArgs   map[string]string `json:"dockerBuildArgs"`
+	NetworkMode       string            `json:"networkMode"`
+	Platform          string            `json:"platform"`
+	// Executable produces the image with a command, which the runner does not support
+	Executable []string `json:"executable"`
+}
+
+type ImageDestination struct {
+	Region               string `json:"region"`
+	AssumeRoleArn        string `json:"assumeRoleArn"`
+	AssumeRoleExternalID string `json:"assumeRoleExternalId"`
+	RepositoryName       string `json:"repositoryName"`
+	ImageTag             string `json:"imageTag"`
+}
+
+// Load reads the asset manifests of the cloud assembly directory. A directory without manifests, or that does
+// not exist, has no assets.
+func Load(dir string) ([]*Manifest, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.assets.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	var manifests []*Manifest
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		m := &Manifest{dir: dir}
+		if err := json.Unmarshal(data, m); err != nil {
+			return nil, fmt.Errorf("invalid asset manifest %s: %w", file, err)
+		}
+		manifests = append(manifests, m)
+	}
+	return manifests, nil
+}
+
+// sortedKeys returns the keys of the map in order, so assets are published in the same order every run
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
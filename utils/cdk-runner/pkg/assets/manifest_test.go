@@ -0,0 +1,132 @@
+package assets
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testManifest = `{
+  "version": "34.0.0",
+  "files": {
+    "abc": {
+      "source": {"path": "asset.abc", "packaging": "zip"},
+      "destinations": {
+        "123456789012-us-east-1": {
+          "bucketName": "cdk-hnb659fds-assets-${AWS::AccountId}-${AWS::Region}",
+          "objectKey": "abc.zip",
+          "region": "us-east-1",
+          "assumeRoleArn": "arn:${AWS::Partition}:iam::123456789012:role/cdk-hnb659fds-file-publishing-role"
+        }
+      }
+    }
+  },
+  "dockerImages": {
+    "def": {
+      "source": {"directory": "asset.def", "dockerBuildArgs": {"VERSION": "1"}},
+      "destinations": {
+        "123456789012-us-east-1": {
+          "repositoryName": "cdk-hnb659fds-container-assets-123456789012-us-east-1",
+          "imageTag": "def"
+        }
+      }
+    }
+  }
+}`
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name        string
+		files       map[string]string
+		want        []string
+		errContains string
+	}{
+		{
+			name: "no manifests",
+		},
+		{
+			name: "manifests in order",
+			files: map[string]string{
+				"queue.assets.json":    testManifest,
+				"database.assets.json": `{"version": "34.0.0"}`,
+				"manifest.json":        `{"version": "34.0.0"}`,
+				"queue.template.json":  `{}`,
+			},
+			want: []string{"34.0.0/0/0", "34.0.0/1/1"},
+		},
+		{
+			name:        "invalid manifest",
+			files:       map[string]string{"queue.assets.json": `{"files": []}`},
+			errContains: "invalid asset manifest",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			manifests, err := Load(dir)
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Fatalf("expected error to contain %q, got %v", tt.errContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			var got []string
+			for _, m := range manifests {
+				if m.dir != dir {
+					t.Errorf("expected the sources to be relative to %s, got %s", dir, m.dir)
+				}
+				got = append(got, fmt.Sprintf("%s/%d/%d", m.Version, len(m.Files), len(m.DockerImages)))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestLoadMissingDir(t *testing.T) {
+	manifests, err := Load(filepath.Join(t.TempDir(), "cdk.out"))
+	if err != nil || manifests != nil {
+		t.Errorf("expected no manifests for a missing directory, got %v, %v", manifests, err)
+	}
+}
+
+func TestLoadFields(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "queue.assets.json"), []byte(testManifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	manifests, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file := manifests[0].Files["abc"]
+	if file.Source.Path != "asset.abc" || file.Source.Packaging != PackagingZip {
+		t.Errorf("unexpected file source %+v", file.Source)
+	}
+	if dest := file.Destinations["123456789012-us-east-1"]; dest.ObjectKey != "abc.zip" || dest.Region != "us-east-1" {
+		t.Errorf("unexpected file destination %+v", dest)
+	}
+	image := manifests[0].DockerImages["def"]
+	if image.Source.Directory != "asset.def" || image.Source.DockerBuildArgs["VERSION"] != "1" {
+		t.Errorf("unexpected image source %+v", image.Source)
+	}
+	if dest := image.Destinations["123456789012-us-east-1"]; dest.ImageTag != "def" {
+		t.Errorf("unexpected image destination %+v", dest)
+	}
+}
@@ -0,0 +1,147 @@
+package assets
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/acorn-io/aws/utils/cdk-runner/pkg/aws/cloudformation"
+	"github.com/acorn-io/aws/utils/cdk-runner/pkg/report"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// RegistryEnvKey replaces the ECR registry of the image assets, like with a local registry
+	RegistryEnvKey = "CDK_ASSETS_REGISTRY"
+	// DockerEnvKey is the Docker compatible command images are built and pushed with, as with the CDK CLI
+	DockerEnvKey = "CDK_DOCKER"
+)
+
+// Publisher publishes the assets of a cloud assembly before its stack is deployed
+type Publisher struct {
+	Ctx context.Context
+	// Config is the AWS config of the runner, the destinations assume their role from it when they have one
+	Config aws.Config
+	// Registry replaces the ECR registry of the image assets when set, without ECR authentication
+	Registry string
+	// Docker is the command images are built and pushed with
+	Docker string
+	// Report records the published assets when set
+	Report *report.Report
+
+	account string
+}
+
+func NewPublisher(ctx context.Context, cfg aws.Config) *Publisher {
+	docker := os.Getenv(DockerEnvKey)
+	if docker == "" {
+		docker = "docker"
+	}
+	return &Publisher{
+		Ctx:      ctx,
+		Config:   cfg,
+		Registry: os.Getenv(RegistryEnvKey),
+		Docker:   docker,
+	}
+}
+
+// Publish uploads the file assets and pushes the image assets of the manifests of the cloud assembly directory.
+// Assets already at their destination, from an earlier run, are skipped.
+func (p *Publisher) Publish(dir string) error {
+	manifests, err := Load(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range manifests {
+		for _, id := range sortedKeys(m.Files) {
+			if err := p.publishFile(m, id, m.Files[id]); err != nil {
+				return fmt.Errorf("failed to publish file asset %s: %w", id, err)
+			}
+		}
+		for _, id := range sortedKeys(m.DockerImages) {
+			if err := p.publishImage(m, id, m.DockerImages[id]); err != nil {
+				return fmt.Errorf("failed to publish image asset %s: %w", id, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (p *Publisher) record(id, assetType, destination string, published bool) {
+	if published {
+		logrus.Infof("Published %s asset %s to %s", assetType, id, destination)
+	} else {
+		logrus.Infof("Skipping %s asset %s, it is already published to %s", assetType, id, destination)
+	}
+	p.Report.AddAsset(report.Asset{ID: id, Type: assetType, Destination: destination, Published: published})
+}
+
+// config returns the AWS config of a destination, in its region and with its role
+func (p *Publisher) config(region, roleArn, externalID string) aws.Config {
+	cfg := p.Config.Copy()
+	if region != "" {
+		cfg.Region = region
+	}
+	if roleArn != "" {
+		cfg.Credentials = aws.NewCredentialsCache(stscreds.NewAssumeRoleProvider(sts.NewFromConfig(p.Config), roleArn, func(o *stscreds.AssumeRoleOptions) {
+			o.RoleSessionName = "cdk-runner-assets"
+			if externalID != "" {
+				o.ExternalID = aws.String(externalID)
+			}
+		}))
+	}
+	return cfg
+}
+
+func (p *Publisher) s3Client(cfg aws.Config) *s3.Client {
+	return s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if endpoint := os.Getenv(cloudformation.S3EndpointURLEnvKey); endpoint != "" {
+			o.BaseEndpoint = aws.String(endpoint)
+			o.UsePathStyle = true
+		}
+	})
+}
+
+// resolve replaces the ${AWS::AccountId}, ${AWS::Region} and ${AWS::Partition} placeholders of a destination
+func (p *Publisher) resolve(s, region string) (string, error) {
+	if region == "" {
+		region = p.Config.Region
+	}
+	if strings.Contains(s, "${AWS::AccountId}") {
+		account, err := p.accountID()
+		if err != nil {
+			return "", err
+		}
+		s = strings.ReplaceAll(s, "${AWS::AccountId}", account)
+	}
+	s = strings.ReplaceAll(s, "${AWS::Region}", region)
+	return strings.ReplaceAll(s, "${AWS::Partition}", partition(region)), nil
+}
+
+func (p *Publisher) accountID() (string, error) {
+	if p.account != "" {
+		return p.account, nil
+	}
+	identity, err := sts.NewFromConfig(p.Config).GetCallerIdentity(p.Ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get the account of the asset destinations: %w", err)
+	}
+	p.account = aws.ToString(identity.Account)
+	return p.account, nil
+}
+
+func partition(region string) string {
+	switch {
+	case strings.HasPrefix(region, "cn-"):
+		return "aws-cn"
+	case strings.HasPrefix(region, "us-gov-"):
+		return "aws-us-gov"
+	}
+	return "aws"
+}
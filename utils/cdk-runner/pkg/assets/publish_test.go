@@ -0,0 +1,59 @@
+package assets
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		name   string
+		s      string
+		region string
+		want   string
+	}{
+		{
+			name: "no placeholders",
+			s:    "my-bucket",
+			want: "my-bucket",
+		},
+		{
+			name: "region of the runner",
+			s:    "cdk-hnb659fds-assets-${AWS::AccountId}-${AWS::Region}",
+			want: "cdk-hnb659fds-assets-123456789012-us-east-2",
+		},
+		{
+			name:   "region of the destination",
+			s:      "cdk-hnb659fds-assets-${AWS::AccountId}-${AWS::Region}",
+			region: "eu-west-1",
+			want:   "cdk-hnb659fds-assets-123456789012-eu-west-1",
+		},
+		{
+			name:   "china partition",
+			s:      "arn:${AWS::Partition}:iam::${AWS::AccountId}:role/publishing",
+			region: "cn-north-1",
+			want:   "arn:aws-cn:iam::123456789012:role/publishing",
+		},
+		{
+			name:   "gov cloud partition",
+			s:      "arn:${AWS::Partition}:iam::${AWS::AccountId}:role/publishing",
+			region: "us-gov-west-1",
+			want:   "arn:aws-us-gov:iam::123456789012:role/publishing",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The account is looked up once, set it so the test does not call STS
+			p := &Publisher{Config: aws.Config{Region: "us-east-2"}, account: "123456789012"}
+			got, err := p.resolve(tt.s, tt.region)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
@@ -2,15 +2,56 @@ package cloudformation
 
 import (
 	"context"
+	"os"
+	"time"
 
 	"github.com/acorn-io/aws/utils/cdk-runner/pkg/aws/utils"
+	"github.com/acorn-io/aws/utils/cdk-runner/pkg/hooks"
+	"github.com/acorn-io/aws/utils/cdk-runner/pkg/report"
+	"github.com/acorn-io/aws/utils/cdk-runner/pkg/timeouts"
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
 )
 
 type Client struct {
 	Ctx    context.Context
 	Client *cloudformation.Client
+	// Config is the AWS config the clients are created from
+	Config aws.Config
+	// S3 stages the templates too large to send inline
+	S3 *s3.Client
+	// Report records the run when set
+	Report *report.Report
+	// Hooks is the context of the hooks the client runs
+	Hooks hooks.Context
+	// DryRun stops deployments once the change set is created
+	DryRun bool
+	// DiffFormat is the format of the diff printed on dry runs, text or markdown
+	DiffFormat string
+	// Timeouts bound the waits of the client and set how often it polls
+	Timeouts timeouts.Config
+	// TemplateBucket is the bucket large templates are staged in, the bucket of the account is used when empty
+	TemplateBucket string
+	// StartTime is when the run started, the stack events before it belong to earlier operations
+	StartTime time.Time
+}
+
+const (
+	// EndpointURLEnvKey points the client at another CloudFormation endpoint, like a local emulator
+	EndpointURLEnvKey = "AWS_ENDPOINT_URL_CLOUDFORMATION"
+	// DiffFormatEnvKey sets the format of the diff printed on dry runs
+	DiffFormatEnvKey = "DIFF_FORMAT"
+
+	// eventClockSkew is how far the clock of the runner may be ahead of the one of CloudFormation, the events
+	// are listed from this long before the run started
+	eventClockSkew = time.Minute
+)
+
+// eventsSince returns the time the stack events of the run start at
+func (c *Client) eventsSince() time.Time {
+	return c.StartTime.Add(-eventClockSkew)
 }
 
 func NewClient(ctx context.Context) (*Client, error) {
@@ -20,8 +61,25 @@ func NewClient(ctx context.Context) (*Client, error) {
 	}
 
 	client := &Client{
-		Ctx:    ctx,
-		Client: cloudformation.NewFromConfig(cfg),
+		Ctx: ctx,
+		Client: cloudformation.NewFromConfig(cfg, func(o *cloudformation.Options) {
+			if endpoint := os.Getenv(EndpointURLEnvKey); endpoint != "" {
+				o.BaseEndpoint = aws.String(endpoint)
+			}
+		}),
+		Config: cfg,
+		S3: s3.NewFromConfig(cfg, func(o *s3.Options) {
+			// Emulators and MinIO serve buckets as paths rather than subdomains
+			if endpoint := os.Getenv(S3EndpointURLEnvKey); endpoint != "" {
+				o.BaseEndpoint = aws.String(endpoint)
+				o.UsePathStyle = true
+			}
+		}),
+		DryRun:         IsDryRun(),
+		DiffFormat:     os.Getenv(DiffFormatEnvKey),
+		Timeouts:       timeouts.Default(),
+		TemplateBucket: os.Getenv(TemplateBucketEnvKey),
+		StartTime:      time.Now(),
 	}
 
 	if err := utils.WaitForClientRole(ctx); err != nil {
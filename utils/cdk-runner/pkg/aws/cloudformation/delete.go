@@ -3,7 +3,6 @@ package cloudformation
 import (
 	"fmt"
 	"os"
-	"time"
 
 	"github.com/acorn-io/aws/utils/cdk-runner/pkg/cdk"
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -16,11 +15,11 @@ func Delete(c *Client, stackName string) error {
 
 	// Check if stack exists
 	stack, err := GetStack(c, stackName)
-	if !stack.Exists {
+	if err != nil && !IsNotExist(err) {
+		return err
+	} else if !stack.Exists {
 		// Doesn't exist and user is trying to delete, so we're good
 		return nil
-	} else if err != nil {
-		return err
 	}
 
 	if stack.DeletionProtection && os.Getenv(DeletionProtectionEnvKey) == "true" {
@@ -57,5 +56,5 @@ func Delete(c *Client, stackName string) error {
 
 	return deleteStackWaiter.Wait(c.Ctx, &cloudformation.DescribeStacksInput{
 		StackName: aws.String(stackName),
-	}, time.Minute*60)
+	}, c.Timeouts.Operation)
 }
@@ -7,7 +7,9 @@ import (
 	"strings"
 	"time"
 
+	"github.com/acorn-io/aws/utils/cdk-runner/pkg/diff"
 	"github.com/acorn-io/aws/utils/cdk-runner/pkg/hooks"
+	"github.com/acorn-io/aws/utils/cdk-runner/pkg/policy"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
 	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
@@ -23,6 +25,13 @@ const (
 	DryRunEnvKey                   = "DRY_RUN"
 )
 
+var (
+	// CurrentTemplateFile is where the template of the deployed stack is written before an update
+	CurrentTemplateFile = "/app/current-template.yaml"
+	// ChangeSetFile is where the changes of the change set are written
+	ChangeSetFile = "/app/change-set.json"
+)
+
 // IsDryRun returns true if the current run is a dry run
 func IsDryRun() bool {
 	return os.Getenv(DryRunEnvKey) == "true"
@@ -61,26 +70,63 @@ func DeployStack(c *Client, stackName, template string) error {
 		return err
 	}
 
-	if err := os.WriteFile("/app/current-template.yaml", currentTemplate, 0644); err != nil {
+	if err := os.WriteFile(CurrentTemplateFile, currentTemplate, 0644); err != nil {
+		return err
+	}
+
+	if err := CheckDrift(c, stack, template); err != nil {
+		return err
+	}
+
+	// CloudFormation copies a staged template when it creates the change set, it is deleted once the run is over
+	staged, err := StageTemplate(c, stackName, template)
+	if err != nil {
+		return err
+	}
+	defer staged.Cleanup(c)
+
+	changeSetOutput, err := createAndWaitForChangeset(c, stack, template, staged)
+	if err != nil {
+		return err
+	}
+	if changeSetOutput == nil {
+		logrus.Infof("Stack %s has no changes", stackName)
+		if c.DryRun {
+			// Reviewers of a dry run get an explicit answer rather than no diff at all
+			return (&diff.Diff{}).Render(os.Stdout, c.DiffFormat)
+		}
+		return nil
+	}
+
+	changes, err := outputChangesInChangeSet(c, *changeSetOutput.Id, stack, currentTemplate, []byte(template))
+	if err != nil {
 		return err
 	}
 
-	changeSetOutput, err := createAndWaitForChangeset(c, stack, template)
-	if err != nil || changeSetOutput == nil {
+	if c.DryRun {
+		if err := printDiff(c, changes, currentTemplate, []byte(template)); err != nil {
+			return err
+		}
+	}
+
+	if err := checkReplacements(changes, currentTemplate, []byte(template)); err != nil {
 		return err
 	}
 
-	if err := outputChangesInChangeSet(c, *changeSetOutput.Id, stack); err != nil {
+	if err := checkPolicies(c, changes, currentTemplate, []byte(template)); err != nil {
 		return err
 	}
 
-	if err := hooks.RunChangesetHook(hooks.PreChangeSetApplyHookExecutable); err != nil {
+	hctx := c.Hooks
+	hctx.CurrentTemplate = CurrentTemplateFile
+	hctx.ChangeSet = ChangeSetFile
+	if err := hooks.Run(hooks.PreChangeSetApply, hctx); err != nil {
 		return err
 	}
 
-	if IsDryRun() {
+	if c.DryRun {
 		// run the dry run hook and end execution here
-		return hooks.RunChangesetHook(hooks.DryRunHookExecutable)
+		return hooks.Run(hooks.DryRun, hctx)
 	}
 
 	stack.Refresh(c)
@@ -91,7 +137,7 @@ func DeployStack(c *Client, stackName, template string) error {
 	return nil
 }
 
-func createAndWaitForChangeset(c *Client, stack *CfnStack, template string) (*cloudformation.CreateChangeSetOutput, error) {
+func createAndWaitForChangeset(c *Client, stack *CfnStack, template string, staged *StagedTemplate) (*cloudformation.CreateChangeSetOutput, error) {
 	createChangeSetWaiter := cloudformation.NewChangeSetCreateCompleteWaiter(c.Client)
 
 	changeSetType := types.ChangeSetTypeCreate
@@ -102,17 +148,23 @@ func createAndWaitForChangeset(c *Client, stack *CfnStack, template string) (*cl
 	tags := getTags()
 
 	logrus.Infof("Creating changeset for: %s", stack.StackName)
-	changeSetOutput, err := c.Client.CreateChangeSet(c.Ctx, &cloudformation.CreateChangeSetInput{
+	input := &cloudformation.CreateChangeSetInput{
 		ChangeSetName: aws.String(fmt.Sprintf("%s-%d", stack.StackName, time.Now().Unix())),
 		StackName:     aws.String(stack.StackName),
-		TemplateBody:  aws.String(template),
 		Capabilities: []types.Capability{
 			types.CapabilityCapabilityIam,
 			types.CapabilityCapabilityNamedIam,
+			types.CapabilityCapabilityAutoExpand,
 		},
 		ChangeSetType: changeSetType,
 		Tags:          tags,
-	})
+	}
+	if staged != nil {
+		input.TemplateURL = aws.String(staged.URL)
+	} else {
+		input.TemplateBody = aws.String(template)
+	}
+	changeSetOutput, err := c.Client.CreateChangeSet(c.Ctx, input)
 	if err != nil {
 		return nil, err
 	}
@@ -121,7 +173,7 @@ func createAndWaitForChangeset(c *Client, stack *CfnStack, template string) (*cl
 	if err := createChangeSetWaiter.Wait(c.Ctx, &cloudformation.DescribeChangeSetInput{
 		ChangeSetName: changeSetOutput.Id,
 		StackName:     aws.String(stack.StackName),
-	}, time.Minute*1); err != nil {
+	}, c.Timeouts.ChangeSet); err != nil {
 		output, describeErr := c.Client.DescribeChangeSet(c.Ctx, &cloudformation.DescribeChangeSetInput{
 			ChangeSetName: changeSetOutput.Id,
 			StackName:     aws.String(stack.StackName),
@@ -141,30 +193,80 @@ func createAndWaitForChangeset(c *Client, stack *CfnStack, template string) (*cl
 	return changeSetOutput, nil
 }
 
-func outputChangesInChangeSet(c *Client, changeSetId string, stack *CfnStack) error {
+func outputChangesInChangeSet(c *Client, changeSetId string, stack *CfnStack, currentTemplate, newTemplate []byte) ([]types.Change, error) {
 	describeChangeSetOutput, err := c.Client.DescribeChangeSet(c.Ctx, &cloudformation.DescribeChangeSetInput{
 		ChangeSetName: aws.String(changeSetId),
 		StackName:     aws.String(stack.StackName),
 	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	bytes, err := json.Marshal(describeChangeSetOutput.Changes)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	logrus.Info("Writing changeset to /app/change-set.json")
-	if err := os.WriteFile("/app/change-set.json", bytes, 0644); err != nil {
-		return err
+	c.Report.SetChangeSet(changeSetId, describeChangeSetOutput.Changes)
+
+	logrus.Infof("Writing changeset to %s", ChangeSetFile)
+	if err := os.WriteFile(ChangeSetFile, bytes, 0644); err != nil {
+		return nil, err
 	}
 
 	for _, change := range describeChangeSetOutput.Changes {
 		logrus.Infof("  %s: %s", change.ResourceChange.Action, *change.ResourceChange.LogicalResourceId)
+
+		if change.ResourceChange.Action != types.ChangeActionModify {
+			continue
+		}
+		params, err := rebootRequiredParameters(currentTemplate, newTemplate, *change.ResourceChange.LogicalResourceId)
+		if err != nil {
+			return nil, err
+		}
+		if len(params) > 0 {
+			logrus.Warnf("    static parameters %s of %s changed, they take effect once the instances are rebooted", strings.Join(params, ", "), *change.ResourceChange.LogicalResourceId)
+		}
 	}
 
-	return nil
+	return describeChangeSetOutput.Changes, nil
+}
+
+// printDiff prints the differences between the templates, with the actions of the change set, for the reviewers of
+// a dry run
+func printDiff(c *Client, changes []types.Change, currentTemplate, newTemplate []byte) error {
+	d, err := diff.Compute(currentTemplate, newTemplate, changes)
+	if err != nil {
+		return err
+	}
+	return d.Render(os.Stdout, c.DiffFormat)
+}
+
+// checkPolicies evaluates the rules of the policy directory, logs the violations and fails on the ones that deny
+func checkPolicies(c *Client, changes []types.Change, currentTemplate, newTemplate []byte) error {
+	rules, err := policy.Load(policy.Dir())
+	if err != nil {
+		return err
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+
+	logrus.Infof("Evaluating %d policy rules", len(rules))
+	violations, err := policy.Evaluate(rules, changes, currentTemplate, newTemplate)
+	if err != nil {
+		return err
+	}
+	c.Report.SetViolations(violations)
+
+	for _, v := range violations {
+		if v.Severity == policy.SeverityDeny {
+			logrus.Errorf("  policy violation: %s", v)
+		} else {
+			logrus.Warnf("  policy warning: %s", v)
+		}
+	}
+	return policy.Denied(violations)
 }
 
 func executeChangeSetAndWait(c *Client, changeSetId string, stack *CfnStack) error {
@@ -183,14 +285,14 @@ func executeChangeSetAndWait(c *Client, changeSetId string, stack *CfnStack) err
 	if stack.Exists {
 		if err := updateWaiter.Wait(c.Ctx, &cloudformation.DescribeStacksInput{
 			StackName: aws.String(stack.StackName),
-		}, time.Minute*60); err != nil {
+		}, c.Timeouts.Operation); err != nil {
 			return err
 		}
 		return nil
 	}
 	return createWaiter.Wait(c.Ctx, &cloudformation.DescribeStacksInput{
 		StackName: aws.String(stack.StackName),
-	}, time.Minute*60)
+	}, c.Timeouts.Operation)
 }
 
 func getTags() []types.Tag {
@@ -0,0 +1,177 @@
+package cloudformation
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/acorn-io/aws/utils/cdk-runner/pkg/report"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	"github.com/sirupsen/logrus"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// DriftPolicyEnvKey sets the drift policy of the stack, overriding the one of the template
+	DriftPolicyEnvKey = "DRIFT_POLICY"
+	// DriftPolicyMetadataKey is the template metadata key a stack sets its drift policy with
+	DriftPolicyMetadataKey = "acorn.io/drift-policy"
+
+	// DriftPolicyOff skips the drift detection
+	DriftPolicyOff = "off"
+	// DriftPolicyReport records the drifted resources in the deployment report
+	DriftPolicyReport = "report"
+	// DriftPolicyWarn also logs the drifted resources as warnings
+	DriftPolicyWarn = "warn"
+	// DriftPolicyBlock fails the update when resources drifted, before the change set is created
+	DriftPolicyBlock = "block"
+)
+
+// driftPolicy returns the drift policy of the stack, from the env or the metadata of the new template. Drift
+// detection is off by default.
+func driftPolicy(template string) (string, error) {
+	policy := os.Getenv(DriftPolicyEnvKey)
+	if policy == "" {
+		var t struct {
+			Metadata map[string]any `json:"Metadata"`
+		}
+		if err := yaml.Unmarshal([]byte(template), &t); err != nil {
+			return "", err
+		}
+		policy, _ = t.Metadata[DriftPolicyMetadataKey].(string)
+	}
+
+	switch policy {
+	case "":
+		return DriftPolicyOff, nil
+	case DriftPolicyOff, DriftPolicyReport, DriftPolicyWarn, DriftPolicyBlock:
+		return policy, nil
+	}
+	return "", fmt.Errorf("drift policy %q is not %s, %s, %s or %s", policy, DriftPolicyOff, DriftPolicyReport, DriftPolicyWarn, DriftPolicyBlock)
+}
+
+// CheckDrift detects the drift of an existing stack and handles it as its drift policy says
+func CheckDrift(c *Client, stack *CfnStack, template string) error {
+	if !stack.Exists {
+		return nil
+	}
+
+	policy, err := driftPolicy(template)
+	if err != nil || policy == DriftPolicyOff {
+		return err
+	}
+
+	drift, err := detectDrift(c.Ctx, c.Client, stack.StackName, c.Timeouts.DriftPoll)
+	if err != nil {
+		return err
+	}
+	drift.Policy = policy
+	c.Report.SetDrift(drift)
+
+	if len(drift.Resources) == 0 {
+		logrus.Infof("Stack %s has not drifted", stack.StackName)
+		return nil
+	}
+
+	log := logrus.Infof
+	if policy != DriftPolicyReport {
+		log = logrus.Warnf
+	}
+	var drifted []string
+	for _, r := range drift.Resources {
+		log("  drifted: %s %s %s", r.Status, r.ResourceType, r.LogicalID)
+		for _, p := range r.Properties {
+			log("    %s %s: expected %s, actual %s", p.DifferenceType, p.Path, p.Expected, p.Actual)
+		}
+		drifted = append(drifted, r.LogicalID)
+	}
+
+	if policy == DriftPolicyBlock {
+		return fmt.Errorf("resources %s of stack %s were changed outside of CloudFormation, the update would overwrite the changes. "+
+			"Add them to the stack, or revert them, or set %s to %s to update anyway",
+			strings.Join(drifted, ", "), stack.StackName, DriftPolicyEnvKey, DriftPolicyWarn)
+	}
+	return nil
+}
+
+// driftAPIClient is the part of the CloudFormation API the drift detection uses
+type driftAPIClient interface {
+	cloudformation.DescribeStackResourceDriftsAPIClient
+	DetectStackDrift(context.Context, *cloudformation.DetectStackDriftInput, ...func(*cloudformation.Options)) (*cloudformation.DetectStackDriftOutput, error)
+	DescribeStackDriftDetectionStatus(context.Context, *cloudformation.DescribeStackDriftDetectionStatusInput, ...func(*cloudformation.Options)) (*cloudformation.DescribeStackDriftDetectionStatusOutput, error)
+}
+
+// detectDrift runs the drift detection of the stack, waits for it, checking its status every poll, and returns the
+// modified and deleted resources
+func detectDrift(ctx context.Context, client driftAPIClient, stackName string, poll time.Duration) (*report.Drift, error) {
+	logrus.Infof("Detecting drift of stack %s", stackName)
+	detection, err := client.DetectStackDrift(ctx, &cloudformation.DetectStackDriftInput{
+		StackName: aws.String(stackName),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var status *cloudformation.DescribeStackDriftDetectionStatusOutput
+	for {
+		status, err = client.DescribeStackDriftDetectionStatus(ctx, &cloudformation.DescribeStackDriftDetectionStatusInput{
+			StackDriftDetectionId: detection.StackDriftDetectionId,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if status.DetectionStatus != types.StackDriftDetectionStatusDetectionInProgress {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(poll):
+		}
+	}
+
+	// A failed detection still has the results of the resources it could check
+	if status.DetectionStatus == types.StackDriftDetectionStatusDetectionFailed {
+		logrus.Warnf("Drift detection of stack %s failed for some resources: %s", stackName, aws.ToString(status.DetectionStatusReason))
+	}
+
+	drift := &report.Drift{
+		Status:    string(status.StackDriftStatus),
+		Resources: []report.DriftResource{},
+	}
+
+	paginator := cloudformation.NewDescribeStackResourceDriftsPaginator(client, &cloudformation.DescribeStackResourceDriftsInput{
+		StackName: aws.String(stackName),
+		StackResourceDriftStatusFilters: []types.StackResourceDriftStatus{
+			types.StackResourceDriftStatusModified,
+			types.StackResourceDriftStatusDeleted,
+		},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range page.StackResourceDrifts {
+			r := report.DriftResource{
+				LogicalID:    aws.ToString(d.LogicalResourceId),
+				ResourceType: aws.ToString(d.ResourceType),
+				Status:       string(d.StackResourceDriftStatus),
+			}
+			for _, p := range d.PropertyDifferences {
+				r.Properties = append(r.Properties, report.DriftProperty{
+					Path:           aws.ToString(p.PropertyPath),
+					Expected:       aws.ToString(p.ExpectedValue),
+					Actual:         aws.ToString(p.ActualValue),
+					DifferenceType: string(p.DifferenceType),
+				})
+			}
+			drift.Resources = append(drift.Resources, r)
+		}
+	}
+	return drift, nil
+}
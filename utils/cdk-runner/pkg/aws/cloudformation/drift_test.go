@@ -0,0 +1,234 @@
+package cloudformation
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/acorn-io/aws/utils/cdk-runner/pkg/report"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsCfn "github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+)
+
+func TestDriftPolicy(t *testing.T) {
+	withPolicy := func(policy string) string {
+		return "Metadata:\n  acorn.io/drift-policy: " + policy + "\nResources: {}\n"
+	}
+
+	tests := []struct {
+		name        string
+		env         string
+		template    string
+		want        string
+		errContains string
+	}{
+		{
+			name:     "default",
+			template: "Resources: {}\n",
+			want:     DriftPolicyOff,
+		},
+		{
+			name:     "metadata",
+			template: withPolicy("warn"),
+			want:     DriftPolicyWarn,
+		},
+		{
+			name:     "env overrides the metadata",
+			env:      "block",
+			template: withPolicy("warn"),
+			want:     DriftPolicyBlock,
+		},
+		{
+			name:        "unknown metadata policy",
+			template:    withPolicy("fail"),
+			errContains: `drift policy "fail" is not off, report, warn or block`,
+		},
+		{
+			name:        "unknown env policy",
+			env:         "ignore",
+			template:    withPolicy("warn"),
+			errContains: `drift policy "ignore"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(DriftPolicyEnvKey, tt.env)
+			got, err := driftPolicy(tt.template)
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Fatalf("expected error to contain %q, got %v", tt.errContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+// fakeDrift answers the detection status calls in order, and lists the resource drifts in pages
+type fakeDrift struct {
+	detectErr error
+	statuses  []awsCfn.DescribeStackDriftDetectionStatusOutput
+	pages     [][]types.StackResourceDrift
+
+	statusCalls int
+	filters     []types.StackResourceDriftStatus
+}
+
+func (f *fakeDrift) DetectStackDrift(_ context.Context, in *awsCfn.DetectStackDriftInput, _ ...func(*awsCfn.Options)) (*awsCfn.DetectStackDriftOutput, error) {
+	if f.detectErr != nil {
+		return nil, f.detectErr
+	}
+	return &awsCfn.DetectStackDriftOutput{StackDriftDetectionId: aws.String("detection-" + aws.ToString(in.StackName))}, nil
+}
+
+func (f *fakeDrift) DescribeStackDriftDetectionStatus(_ context.Context, in *awsCfn.DescribeStackDriftDetectionStatusInput, _ ...func(*awsCfn.Options)) (*awsCfn.DescribeStackDriftDetectionStatusOutput, error) {
+	if aws.ToString(in.StackDriftDetectionId) != "detection-my-stack" {
+		return nil, errors.New("unknown detection " + aws.ToString(in.StackDriftDetectionId))
+	}
+	status := f.statuses[f.statusCalls]
+	f.statusCalls++
+	return &status, nil
+}
+
+func (f *fakeDrift) DescribeStackResourceDrifts(_ context.Context, in *awsCfn.DescribeStackResourceDriftsInput, _ ...func(*awsCfn.Options)) (*awsCfn.DescribeStackResourceDriftsOutput, error) {
+	f.filters = in.StackResourceDriftStatusFilters
+	page := 0
+	if in.NextToken != nil {
+		page = int(aws.ToString(in.NextToken)[0] - '0')
+	}
+	out := &awsCfn.DescribeStackResourceDriftsOutput{StackResourceDrifts: f.pages[page]}
+	if page+1 < len(f.pages) {
+		out.NextToken = aws.String(string(rune('0' + page + 1)))
+	}
+	return out, nil
+}
+
+func TestDetectDrift(t *testing.T) {
+	inProgress := awsCfn.DescribeStackDriftDetectionStatusOutput{DetectionStatus: types.StackDriftDetectionStatusDetectionInProgress}
+	complete := func(status types.StackDriftStatus) awsCfn.DescribeStackDriftDetectionStatusOutput {
+		return awsCfn.DescribeStackDriftDetectionStatusOutput{
+			DetectionStatus:  types.StackDriftDetectionStatusDetectionComplete,
+			StackDriftStatus: status,
+		}
+	}
+	cluster := types.StackResourceDrift{
+		LogicalResourceId:        aws.String("Cluster"),
+		ResourceType:             aws.String("AWS::RDS::DBCluster"),
+		StackResourceDriftStatus: types.StackResourceDriftStatusModified,
+		PropertyDifferences: []types.PropertyDifference{{
+			PropertyPath:   aws.String("/BackupRetentionPeriod"),
+			ExpectedValue:  aws.String("7"),
+			ActualValue:    aws.String("14"),
+			DifferenceType: types.DifferenceTypeNotEqual,
+		}},
+	}
+	secret := types.StackResourceDrift{
+		LogicalResourceId:        aws.String("Secret"),
+		ResourceType:             aws.String("AWS::SecretsManager::Secret"),
+		StackResourceDriftStatus: types.StackResourceDriftStatusDeleted,
+	}
+	wantCluster := report.DriftResource{
+		LogicalID:    "Cluster",
+		ResourceType: "AWS::RDS::DBCluster",
+		Status:       "MODIFIED",
+		Properties: []report.DriftProperty{{
+			Path:           "/BackupRetentionPeriod",
+			Expected:       "7",
+			Actual:         "14",
+			DifferenceType: "NOT_EQUAL",
+		}},
+	}
+	wantSecret := report.DriftResource{LogicalID: "Secret", ResourceType: "AWS::SecretsManager::Secret", Status: "DELETED"}
+
+	tests := []struct {
+		name        string
+		client      *fakeDrift
+		want        *report.Drift
+		statusCalls int
+		errContains string
+	}{
+		{
+			name: "in sync",
+			client: &fakeDrift{
+				statuses: []awsCfn.DescribeStackDriftDetectionStatusOutput{complete(types.StackDriftStatusInSync)},
+				pages:    [][]types.StackResourceDrift{{}},
+			},
+			want:        &report.Drift{Status: "IN_SYNC", Resources: []report.DriftResource{}},
+			statusCalls: 1,
+		},
+		{
+			name: "waits for the detection and follows the pages",
+			client: &fakeDrift{
+				statuses: []awsCfn.DescribeStackDriftDetectionStatusOutput{inProgress, inProgress, complete(types.StackDriftStatusDrifted)},
+				pages:    [][]types.StackResourceDrift{{cluster}, {secret}},
+			},
+			want:        &report.Drift{Status: "DRIFTED", Resources: []report.DriftResource{wantCluster, wantSecret}},
+			statusCalls: 3,
+		},
+		{
+			name: "failed detection keeps the results",
+			client: &fakeDrift{
+				statuses: []awsCfn.DescribeStackDriftDetectionStatusOutput{{
+					DetectionStatus:       types.StackDriftDetectionStatusDetectionFailed,
+					DetectionStatusReason: aws.String("Failed to detect drift on resource Proxy"),
+					StackDriftStatus:      types.StackDriftStatusDrifted,
+				}},
+				pages: [][]types.StackResourceDrift{{cluster}},
+			},
+			want:        &report.Drift{Status: "DRIFTED", Resources: []report.DriftResource{wantCluster}},
+			statusCalls: 1,
+		},
+		{
+			name:        "detection error",
+			client:      &fakeDrift{detectErr: errors.New("access denied")},
+			errContains: "access denied",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			drift, err := detectDrift(context.Background(), tt.client, "my-stack", 0)
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Fatalf("expected error to contain %q, got %v", tt.errContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(drift, tt.want) {
+				t.Errorf("expected %+v, got %+v", tt.want, drift)
+			}
+			if tt.client.statusCalls != tt.statusCalls {
+				t.Errorf("expected %d status checks, got %d", tt.statusCalls, tt.client.statusCalls)
+			}
+			wantFilters := []types.StackResourceDriftStatus{types.StackResourceDriftStatusModified, types.StackResourceDriftStatusDeleted}
+			if !reflect.DeepEqual(tt.client.filters, wantFilters) {
+				t.Errorf("expected only the %v resources to be listed, got %v", wantFilters, tt.client.filters)
+			}
+		})
+	}
+}
+
+func TestDetectDriftCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	client := &fakeDrift{statuses: []awsCfn.DescribeStackDriftDetectionStatusOutput{
+		{DetectionStatus: types.StackDriftDetectionStatusDetectionInProgress},
+	}}
+	if _, err := detectDrift(ctx, client, "my-stack", time.Hour); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the wait to stop when the context is canceled, got %v", err)
+	}
+}
@@ -0,0 +1,122 @@
+package cloudformation
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// AllowReplacementEnvKey lists the logical IDs of the protected resources a change set may replace or remove,
+	// or * to allow all of them
+	AllowReplacementEnvKey = "ALLOW_REPLACEMENT"
+	// ProtectedResourceTypesEnvKey overrides the resource types protected from replacement and removal
+	ProtectedResourceTypesEnvKey = "PROTECTED_RESOURCE_TYPES"
+	// AllowReplacementMetadataKey is the resource metadata key listing the restore properties of a protected
+	// resource, like the snapshot of a cluster. Adding or changing one replaces the resource on purpose.
+	AllowReplacementMetadataKey = "acorn.io/allow-replacement"
+)
+
+// DefaultProtectedResourceTypes are the stateful resource types that lose their data when they are replaced or
+// removed
+var DefaultProtectedResourceTypes = []string{
+	"AWS::DynamoDB::Table",
+	"AWS::ElastiCache::ReplicationGroup",
+	"AWS::KMS::Key",
+	"AWS::RDS::DBCluster",
+	"AWS::RDS::GlobalCluster",
+	"AWS::S3::Bucket",
+}
+
+// checkReplacements returns an error listing every protected resource the change set would replace or remove,
+// unless ALLOW_REPLACEMENT names it, or the replacement restores the resource: one of the restore properties the
+// new template lists in its metadata is added or changed.
+func checkReplacements(changes []types.Change, currentTemplate, newTemplate []byte) error {
+	protected := envList(ProtectedResourceTypesEnvKey)
+	if len(protected) == 0 {
+		protected = DefaultProtectedResourceTypes
+	}
+	allowed := envList(AllowReplacementEnvKey)
+	if contains(allowed, "*") {
+		return nil
+	}
+
+	current, proposed := templateResources{}, templateResources{}
+	if err := yaml.Unmarshal(currentTemplate, &current); err != nil {
+		return err
+	}
+	if err := yaml.Unmarshal(newTemplate, &proposed); err != nil {
+		return err
+	}
+
+	var blocked []string
+	for _, change := range changes {
+		rc := change.ResourceChange
+		if rc == nil || !contains(protected, aws.ToString(rc.ResourceType)) {
+			continue
+		}
+		if rc.Action != types.ChangeActionRemove && rc.Replacement != types.ReplacementTrue {
+			continue
+		}
+
+		logicalID := aws.ToString(rc.LogicalResourceId)
+		if contains(allowed, logicalID) {
+			continue
+		}
+		if rc.Action != types.ChangeActionRemove && restores(current.Resources[logicalID], proposed.Resources[logicalID]) {
+			continue
+		}
+
+		action := "replace"
+		if rc.Action == types.ChangeActionRemove {
+			action = "remove"
+		}
+		blocked = append(blocked, fmt.Sprintf("%s %s %s", action, aws.ToString(rc.ResourceType), logicalID))
+	}
+
+	if len(blocked) > 0 {
+		return fmt.Errorf("the change set would %s, set %s to the logical IDs to allow it", strings.Join(blocked, ", "), AllowReplacementEnvKey)
+	}
+	return nil
+}
+
+// restores returns whether the new resource adds or changes one of the restore properties its metadata lists.
+// Only the new template counts, a resource restored once may not be replaced again without a new restore.
+func restores(current, proposed templateResource) bool {
+	properties, _ := proposed.Metadata[AllowReplacementMetadataKey].([]any)
+	for _, p := range properties {
+		name, ok := p.(string)
+		if !ok {
+			continue
+		}
+		value, ok := proposed.Properties[name]
+		if ok && !reflect.DeepEqual(current.Properties[name], value) {
+			return true
+		}
+	}
+	return false
+}
+
+func envList(key string) []string {
+	var list []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,201 @@
+package cloudformation
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+)
+
+const plainCluster = `
+Resources:
+  Cluster:
+    Type: AWS::RDS::DBCluster
+    Properties:
+      Engine: aurora-mysql
+`
+
+const restoredCluster = `
+Resources:
+  Cluster:
+    Type: AWS::RDS::DBCluster
+    Metadata:
+      acorn.io/allow-replacement:
+        - SnapshotIdentifier
+    Properties:
+      Engine: aurora-mysql
+      SnapshotIdentifier: %s
+`
+
+func restoredFrom(snapshot string) string {
+	return strings.Replace(restoredCluster, "%s", snapshot, 1)
+}
+
+func change(logicalID, resourceType string, action types.ChangeAction, replacement types.Replacement) types.Change {
+	return types.Change{ResourceChange: &types.ResourceChange{
+		LogicalResourceId: aws.String(logicalID),
+		ResourceType:      aws.String(resourceType),
+		Action:            action,
+		Replacement:       replacement,
+	}}
+}
+
+func TestCheckReplacements(t *testing.T) {
+	replaceCluster := change("Cluster", "AWS::RDS::DBCluster", types.ChangeActionModify, types.ReplacementTrue)
+	removeCluster := change("Cluster", "AWS::RDS::DBCluster", types.ChangeActionRemove, "")
+
+	tests := []struct {
+		name        string
+		env         map[string]string
+		changes     []types.Change
+		current     string
+		proposed    string
+		errContains string
+	}{
+		{
+			name:        "replace",
+			changes:     []types.Change{replaceCluster},
+			current:     plainCluster,
+			proposed:    plainCluster,
+			errContains: "the change set would replace AWS::RDS::DBCluster Cluster, set ALLOW_REPLACEMENT",
+		},
+		{
+			name:        "remove",
+			changes:     []types.Change{removeCluster},
+			current:     plainCluster,
+			errContains: "the change set would remove AWS::RDS::DBCluster Cluster",
+		},
+		{
+			name: "every blocked change is listed",
+			changes: []types.Change{
+				replaceCluster,
+				change("Table", "AWS::DynamoDB::Table", types.ChangeActionRemove, ""),
+			},
+			current:     plainCluster,
+			proposed:    plainCluster,
+			errContains: "replace AWS::RDS::DBCluster Cluster, remove AWS::DynamoDB::Table Table",
+		},
+		{
+			name: "modify without replacement",
+			changes: []types.Change{
+				change("Cluster", "AWS::RDS::DBCluster", types.ChangeActionModify, types.ReplacementFalse),
+				change("Cluster", "AWS::RDS::DBCluster", types.ChangeActionModify, types.ReplacementConditional),
+			},
+			current:  plainCluster,
+			proposed: plainCluster,
+		},
+		{
+			name:     "unprotected type",
+			changes:  []types.Change{change("Queue", "AWS::SQS::Queue", types.ChangeActionRemove, "")},
+			current:  plainCluster,
+			proposed: plainCluster,
+		},
+		{
+			name:     "allowed by logical ID",
+			env:      map[string]string{AllowReplacementEnvKey: "Other, Cluster"},
+			changes:  []types.Change{replaceCluster, removeCluster},
+			current:  plainCluster,
+			proposed: plainCluster,
+		},
+		{
+			name:        "allowed for another logical ID",
+			env:         map[string]string{AllowReplacementEnvKey: "Other"},
+			changes:     []types.Change{replaceCluster},
+			current:     plainCluster,
+			proposed:    plainCluster,
+			errContains: "replace AWS::RDS::DBCluster Cluster",
+		},
+		{
+			name:     "all allowed",
+			env:      map[string]string{AllowReplacementEnvKey: "*"},
+			changes:  []types.Change{replaceCluster},
+			current:  plainCluster,
+			proposed: plainCluster,
+		},
+		{
+			name:     "protected types override",
+			env:      map[string]string{ProtectedResourceTypesEnvKey: "AWS::SQS::Queue"},
+			changes:  []types.Change{replaceCluster},
+			current:  plainCluster,
+			proposed: plainCluster,
+		},
+		{
+			name:        "protected types override blocks its types",
+			env:         map[string]string{ProtectedResourceTypesEnvKey: "AWS::SQS::Queue"},
+			changes:     []types.Change{change("Queue", "AWS::SQS::Queue", types.ChangeActionRemove, "")},
+			current:     plainCluster,
+			proposed:    plainCluster,
+			errContains: "remove AWS::SQS::Queue Queue",
+		},
+		{
+			name:     "restore property added",
+			changes:  []types.Change{replaceCluster},
+			current:  plainCluster,
+			proposed: restoredFrom("snapshot-1"),
+		},
+		{
+			name:     "restore property changed",
+			changes:  []types.Change{replaceCluster},
+			current:  restoredFrom("snapshot-1"),
+			proposed: restoredFrom("snapshot-2"),
+		},
+		{
+			name:        "restored before, replaced by another change",
+			changes:     []types.Change{replaceCluster},
+			current:     restoredFrom("snapshot-1"),
+			proposed:    restoredFrom("snapshot-1"),
+			errContains: "replace AWS::RDS::DBCluster Cluster",
+		},
+		{
+			name:        "restore property removed",
+			changes:     []types.Change{replaceCluster},
+			current:     restoredFrom("snapshot-1"),
+			proposed:    plainCluster,
+			errContains: "replace AWS::RDS::DBCluster Cluster",
+		},
+		{
+			name:        "restored cluster removed",
+			changes:     []types.Change{removeCluster},
+			current:     restoredFrom("snapshot-1"),
+			proposed:    restoredFrom("snapshot-2"),
+			errContains: "remove AWS::RDS::DBCluster Cluster",
+		},
+		{
+			name:    "metadata without the restore property",
+			changes: []types.Change{replaceCluster},
+			current: plainCluster,
+			proposed: `
+Resources:
+  Cluster:
+    Type: AWS::RDS::DBCluster
+    Metadata:
+      acorn.io/allow-replacement: true
+    Properties:
+      Engine: aurora-postgresql
+`,
+			errContains: "replace AWS::RDS::DBCluster Cluster",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(AllowReplacementEnvKey, "")
+			t.Setenv(ProtectedResourceTypesEnvKey, "")
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			err := checkReplacements(tt.changes, []byte(tt.current), []byte(tt.proposed))
+			if tt.errContains == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %s", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+				t.Errorf("expected error to contain %q, got %v", tt.errContains, err)
+			}
+		})
+	}
+}
@@ -3,8 +3,24 @@ package cloudformation
 import (
 	"encoding/json"
 	"os"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
 )
 
+// Outputs returns the outputs of the stack by key
+func Outputs(c *Client, stackName string) (map[string]string, error) {
+	stack, err := GetStack(c, stackName)
+	if err != nil {
+		return nil, err
+	}
+
+	outputs := map[string]string{}
+	for _, output := range stack.Current.Outputs {
+		outputs[aws.ToString(output.OutputKey)] = aws.ToString(output.OutputValue)
+	}
+	return outputs, nil
+}
+
 func WriteOutputsToFile(c *Client, stackName, filename string) error {
 	stack, err := GetStack(c, stackName)
 	if err != nil {
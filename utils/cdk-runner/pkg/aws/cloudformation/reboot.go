@@ -0,0 +1,54 @@
+package cloudformation
+
+import (
+	"reflect"
+
+	"sigs.k8s.io/yaml"
+)
+
+// RebootParametersMetadataKey is the resource metadata key listing the static parameters a parameter group sets,
+// that only take effect once the database instances are rebooted.
+const RebootParametersMetadataKey = "acorn.io/reboot-required-parameters"
+
+type templateResources struct {
+	Resources map[string]templateResource `json:"Resources"`
+}
+
+type templateResource struct {
+	Metadata   map[string]any `json:"Metadata"`
+	Properties map[string]any `json:"Properties"`
+}
+
+// rebootRequiredParameters returns the static parameters of the given resource that differ between the current
+// and the new template.
+func rebootRequiredParameters(currentTemplate, newTemplate []byte, logicalID string) ([]string, error) {
+	current, proposed := templateResources{}, templateResources{}
+	if err := yaml.Unmarshal(currentTemplate, &current); err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(newTemplate, &proposed); err != nil {
+		return nil, err
+	}
+
+	// Removing a static parameter needs a reboot as much as setting it does
+	static, _ := proposed.Resources[logicalID].Metadata[RebootParametersMetadataKey].([]any)
+	removed, _ := current.Resources[logicalID].Metadata[RebootParametersMetadataKey].([]any)
+	static = append(static, removed...)
+
+	currentParams, _ := current.Resources[logicalID].Properties["Parameters"].(map[string]any)
+	proposedParams, _ := proposed.Resources[logicalID].Properties["Parameters"].(map[string]any)
+
+	var changed []string
+	seen := map[string]bool{}
+	for _, p := range static {
+		name, ok := p.(string)
+		if !ok || seen[name] {
+			continue
+		}
+		seen[name] = true
+		if !reflect.DeepEqual(currentParams[name], proposedParams[name]) {
+			changed = append(changed, name)
+		}
+	}
+	return changed, nil
+}
@@ -0,0 +1,28 @@
+package cloudformation
+
+import (
+	"github.com/sirupsen/logrus"
+)
+
+// RecordStack adds the latest events, the status and the outputs of the stack to the report of the client. The
+// events are also recorded while LogEvents runs, this catches the ones it has not polled yet.
+func RecordStack(c *Client, stackName string) {
+	if c.Report == nil {
+		return
+	}
+
+	events, err := stackEvents(c.Ctx, c.Client, stackName, c.eventsSince())
+	if err != nil && !IsNotExist(err) {
+		logrus.Warnf("failed to record the events of stack %s: %v", stackName, err)
+	}
+	for _, event := range events {
+		c.Report.AddEvent(event)
+	}
+
+	stack, err := GetStack(c, stackName)
+	if err != nil && !IsNotExist(err) {
+		logrus.Warnf("failed to record the status of stack %s: %v", stackName, err)
+	} else if stack.Exists {
+		c.Report.SetStack(stack.Current)
+	}
+}
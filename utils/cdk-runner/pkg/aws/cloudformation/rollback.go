@@ -1,8 +1,6 @@
 package cloudformation
 
 import (
-	"time"
-
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
 )
@@ -16,7 +14,7 @@ func Rollback(c *Client, stackName string) error {
 	}
 	if err := rollbackWaiter.Wait(c.Ctx, &cloudformation.DescribeStacksInput{
 		StackName: aws.String(stackName),
-	}, time.Minute*60); err != nil {
+	}, c.Timeouts.Operation); err != nil {
 		return err
 	}
 
@@ -1,6 +1,7 @@
 package cloudformation
 
 import (
+	"context"
 	"fmt"
 	"sort"
 	"strings"
@@ -43,6 +44,11 @@ func GetStack(c *Client, stackName string) (*CfnStack, error) {
 	return cStack, err
 }
 
+// IsNotExist returns whether the error of GetStack is that the stack does not exist
+func IsNotExist(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "does not exist")
+}
+
 func (s *CfnStack) Refresh(c *Client) error {
 	stack, err := GetStack(c, s.StackName)
 	if err != nil {
@@ -103,32 +109,26 @@ func StackOperationInProgress(c *Client, stackName string) (bool, string, error)
 }
 
 func (s *CfnStack) LogEvents(c *Client) {
-	if IsDryRun() {
+	if c.DryRun {
 		// don't log events for dry runs
 		return
 	}
 
-	var startTime time.Time
+	startTime := c.eventsSince()
 	termMessage := strings.Builder{}
 	for {
-		events, err := c.Client.DescribeStackEvents(c.Ctx, &awsCfn.DescribeStackEventsInput{
-			StackName: &s.StackName,
-		})
+		events, err := stackEvents(c.Ctx, c.Client, s.StackName, startTime)
 		if err != nil && strings.Contains(err.Error(), "does not exist") {
-			time.Sleep(5 * time.Second)
+			time.Sleep(c.Timeouts.EventPoll)
 			continue
 		} else if err != nil {
 			logrus.Error(err)
+			time.Sleep(c.Timeouts.EventPoll)
 			continue
 		}
 
-		sort.SliceStable(events.StackEvents, func(i, j int) bool {
-			timeI := *events.StackEvents[i].Timestamp
-			timeJ := *events.StackEvents[j].Timestamp
-			return timeI.Before(timeJ)
-		})
-
-		for _, event := range events.StackEvents {
+		for _, event := range events {
+			c.Report.AddEvent(event)
 			if event.Timestamp.After(startTime) {
 				logrus.Infof("%s %s %s %s", event.Timestamp.Format(time.RFC3339), aws.ToString(event.LogicalResourceId), event.ResourceStatus, aws.ToString(event.ResourceStatusReason))
 				if event.ResourceStatus == types.ResourceStatusCreateFailed || event.ResourceStatus == types.ResourceStatusUpdateFailed || event.ResourceStatus == types.ResourceStatusDeleteFailed {
@@ -138,8 +138,35 @@ func (s *CfnStack) LogEvents(c *Client) {
 			}
 		}
 		utils.WriteToTermLogAndError([]byte(termMessage.String()), nil)
-		time.Sleep(5 * time.Second)
+		time.Sleep(c.Timeouts.EventPoll)
+	}
+}
+
+// stackEvents returns the events of the stack since the time, oldest first. The events are listed newest first, so
+// the pages are followed until the first older event.
+func stackEvents(ctx context.Context, client awsCfn.DescribeStackEventsAPIClient, stackName string, since time.Time) ([]types.StackEvent, error) {
+	var events []types.StackEvent
+	paginator := awsCfn.NewDescribeStackEventsPaginator(client, &awsCfn.DescribeStackEventsInput{
+		StackName: aws.String(stackName),
+	})
+pages:
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, event := range page.StackEvents {
+			if event.Timestamp != nil && event.Timestamp.Before(since) {
+				break pages
+			}
+			events = append(events, event)
+		}
 	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return aws.ToTime(events[i].Timestamp).Before(aws.ToTime(events[j].Timestamp))
+	})
+	return events, nil
 }
 
 func (s *CfnStack) GetCurrentTemplate(c *Client) ([]byte, error) {
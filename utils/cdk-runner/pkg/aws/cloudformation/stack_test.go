@@ -0,0 +1,90 @@
+package cloudformation
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsCfn "github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+)
+
+// fakeEvents lists the events in pages, newest first like CloudFormation
+type fakeEvents struct {
+	pages [][]types.StackEvent
+	calls int
+}
+
+func (f *fakeEvents) DescribeStackEvents(_ context.Context, in *awsCfn.DescribeStackEventsInput, _ ...func(*awsCfn.Options)) (*awsCfn.DescribeStackEventsOutput, error) {
+	f.calls++
+	page := 0
+	if in.NextToken != nil {
+		page = int(aws.ToString(in.NextToken)[0] - '0')
+	}
+	out := &awsCfn.DescribeStackEventsOutput{StackEvents: f.pages[page]}
+	if page+1 < len(f.pages) {
+		out.NextToken = aws.String(string(rune('0' + page + 1)))
+	}
+	return out, nil
+}
+
+func TestStackEvents(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	event := func(id string, minutes int) types.StackEvent {
+		return types.StackEvent{EventId: aws.String(id), Timestamp: aws.Time(start.Add(time.Duration(minutes) * time.Minute))}
+	}
+	pages := [][]types.StackEvent{
+		{event("e5", 5), event("e4", 4)},
+		{event("e3", 3), event("e2", 2)},
+		{event("e1", 1), event("old", -1)},
+		{event("older", -2)},
+	}
+
+	tests := []struct {
+		name  string
+		since time.Time
+		want  []string
+		calls int
+	}{
+		{
+			name:  "first page",
+			since: start.Add(4 * time.Minute),
+			want:  []string{"e4", "e5"},
+			calls: 2,
+		},
+		{
+			name:  "stops at the start of the operation",
+			since: start,
+			want:  []string{"e1", "e2", "e3", "e4", "e5"},
+			calls: 3,
+		},
+		{
+			name:  "all pages",
+			since: start.Add(-time.Hour),
+			want:  []string{"older", "old", "e1", "e2", "e3", "e4", "e5"},
+			calls: 4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeEvents{pages: pages}
+			events, err := stackEvents(context.Background(), client, "my-stack", tt.since)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []string
+			for _, e := range events {
+				ids = append(ids, aws.ToString(e.EventId))
+			}
+			if !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, ids)
+			}
+			if client.calls != tt.calls {
+				t.Errorf("expected %d pages to be read, got %d", tt.calls, client.calls)
+			}
+		})
+	}
+}
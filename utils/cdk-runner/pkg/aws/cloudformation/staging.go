@@ -0,0 +1,162 @@
+package cloudformation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// MaxTemplateBodySize is the largest template CloudFormation takes inline, larger ones are staged in S3
+	MaxTemplateBodySize = 51200
+	// TemplateBucketEnvKey sets the bucket large templates are staged in
+	TemplateBucketEnvKey = "TEMPLATE_BUCKET"
+	// S3EndpointURLEnvKey points the S3 client at another endpoint, like MinIO or a local emulator
+	S3EndpointURLEnvKey = "AWS_ENDPOINT_URL_S3"
+
+	// stagedObjectExpirationDays expires the staged templates a run could not delete, like when it was killed
+	stagedObjectExpirationDays = 1
+)
+
+// StagedTemplate is a template uploaded to the staging bucket
+type StagedTemplate struct {
+	Bucket string
+	Key    string
+	URL    string
+}
+
+// StageTemplate uploads the template to the staging bucket when it is too large to send inline, and returns nil
+// otherwise. Without a configured bucket, the bucket of the account and region is used, and created when missing.
+func StageTemplate(c *Client, stackName, template string) (*StagedTemplate, error) {
+	if len(template) <= MaxTemplateBodySize {
+		return nil, nil
+	}
+
+	bucket := c.TemplateBucket
+	if bucket == "" {
+		var err error
+		if bucket, err = defaultTemplateBucket(c); err != nil {
+			return nil, err
+		}
+		if err := ensureTemplateBucket(c, bucket); err != nil {
+			return nil, err
+		}
+	}
+
+	staged := &StagedTemplate{
+		Bucket: bucket,
+		Key:    templateKey(stackName, time.Now()),
+	}
+	staged.URL = templateURL(c, staged.Bucket, staged.Key)
+
+	logrus.Infof("Template is %d bytes, over the inline limit of %d, staging it in s3://%s/%s", len(template), MaxTemplateBodySize, staged.Bucket, staged.Key)
+	if _, err := c.S3.PutObject(c.Ctx, &s3.PutObjectInput{
+		Bucket: aws.String(staged.Bucket),
+		Key:    aws.String(staged.Key),
+		Body:   strings.NewReader(template),
+	}); err != nil {
+		return nil, fmt.Errorf("failed to stage the template in bucket %s: %w", staged.Bucket, err)
+	}
+	return staged, nil
+}
+
+// Cleanup deletes the staged template. Failures are only logged, the bucket expires what is left behind.
+func (s *StagedTemplate) Cleanup(c *Client) {
+	if s == nil {
+		return
+	}
+	// The run context may be done already, like when the deployment timed out
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if _, err := c.S3.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(s.Key),
+	}); err != nil {
+		logrus.Warnf("failed to delete the staged template s3://%s/%s: %v", s.Bucket, s.Key, err)
+		return
+	}
+	logrus.Infof("Deleted the staged template s3://%s/%s", s.Bucket, s.Key)
+}
+
+// defaultTemplateBucket returns the name of the staging bucket of the account and region of the client
+func defaultTemplateBucket(c *Client) (string, error) {
+	identity, err := sts.NewFromConfig(c.Config).GetCallerIdentity(c.Ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get the account of the template bucket, set %s to use a bucket of your own: %w", TemplateBucketEnvKey, err)
+	}
+	return templateBucketName(aws.ToString(identity.Account), c.Config.Region), nil
+}
+
+// templateBucketName returns the name of the staging bucket of the account and region. The Acornfiles grant the
+// runner access to the buckets with its prefix.
+func templateBucketName(account, region string) string {
+	return fmt.Sprintf("cdk-runner-templates-%s-%s", account, region)
+}
+
+// templateKey returns the key a template of the stack is staged at, unique to the run
+func templateKey(stackName string, now time.Time) string {
+	return fmt.Sprintf("%s/%d.yaml", stackName, now.UnixNano())
+}
+
+// ensureTemplateBucket creates the staging bucket when it does not exist, with a rule expiring the templates runs
+// did not delete
+func ensureTemplateBucket(c *Client, bucket string) error {
+	_, err := c.S3.HeadBucket(c.Ctx, &s3.HeadBucketInput{Bucket: aws.String(bucket)})
+	var notFound *s3types.NotFound
+	if err == nil || !errors.As(err, &notFound) {
+		return err
+	}
+
+	logrus.Infof("Creating template bucket %s", bucket)
+	input := &s3.CreateBucketInput{Bucket: aws.String(bucket)}
+	// us-east-1 is the default location and may not be set as a constraint
+	if c.Config.Region != "" && c.Config.Region != "us-east-1" {
+		input.CreateBucketConfiguration = &s3types.CreateBucketConfiguration{
+			LocationConstraint: s3types.BucketLocationConstraint(c.Config.Region),
+		}
+	}
+	if _, err := c.S3.CreateBucket(c.Ctx, input); err != nil {
+		var owned *s3types.BucketAlreadyOwnedByYou
+		if !errors.As(err, &owned) {
+			return fmt.Errorf("failed to create template bucket %s: %w", bucket, err)
+		}
+	}
+
+	_, err = c.S3.PutBucketLifecycleConfiguration(c.Ctx, &s3.PutBucketLifecycleConfigurationInput{
+		Bucket: aws.String(bucket),
+		LifecycleConfiguration: &s3types.BucketLifecycleConfiguration{
+			Rules: []s3types.LifecycleRule{{
+				ID:         aws.String("expire-staged-templates"),
+				Status:     s3types.ExpirationStatusEnabled,
+				Filter:     &s3types.LifecycleRuleFilterMemberPrefix{Value: ""},
+				Expiration: &s3types.LifecycleExpiration{Days: stagedObjectExpirationDays},
+			}},
+		},
+	})
+	return err
+}
+
+// templateURL returns the URL CloudFormation reads the staged template from
+func templateURL(c *Client, bucket, key string) string {
+	if endpoint := os.Getenv(S3EndpointURLEnvKey); endpoint != "" {
+		u, err := url.JoinPath(endpoint, bucket, key)
+		if err == nil {
+			return u
+		}
+	}
+	region := c.Config.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", bucket, region, key)
+}
@@ -0,0 +1,129 @@
+package cloudformation
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+func TestStageTemplate(t *testing.T) {
+	tests := []struct {
+		name   string
+		size   int
+		staged bool
+	}{
+		{name: "small", size: 1024},
+		{name: "at the limit", size: MaxTemplateBodySize},
+		{name: "over the limit", size: MaxTemplateBodySize + 1, staged: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests []string
+			objects := map[string]string{}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests = append(requests, r.Method+" "+r.URL.Path)
+				if r.Method == http.MethodPut {
+					body, _ := io.ReadAll(r.Body)
+					objects[r.URL.Path] = string(body)
+				}
+			}))
+			defer server.Close()
+			t.Setenv(S3EndpointURLEnvKey, server.URL)
+
+			cfg := aws.Config{Region: "us-east-2", Credentials: aws.AnonymousCredentials{}}
+			c := &Client{
+				Ctx:            context.Background(),
+				Config:         cfg,
+				TemplateBucket: "my-templates",
+				S3: s3.NewFromConfig(cfg, func(o *s3.Options) {
+					o.BaseEndpoint = aws.String(server.URL)
+					o.UsePathStyle = true
+				}),
+			}
+
+			template := strings.Repeat("a", tt.size)
+			staged, err := StageTemplate(c, "my-stack", template)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tt.staged {
+				if staged != nil || len(requests) > 0 {
+					t.Errorf("expected the template to be sent inline, got %+v and requests %v", staged, requests)
+				}
+				return
+			}
+
+			if staged.Bucket != "my-templates" || !strings.HasPrefix(staged.Key, "my-stack/") {
+				t.Errorf("expected the template to be staged in my-templates under my-stack/, got %+v", staged)
+			}
+			if want := server.URL + "/my-templates/" + staged.Key; staged.URL != want {
+				t.Errorf("expected URL %s, got %s", want, staged.URL)
+			}
+			if objects["/my-templates/"+staged.Key] != template {
+				t.Errorf("expected the template to be uploaded, got objects %v", requests)
+			}
+
+			staged.Cleanup(c)
+			if last := requests[len(requests)-1]; last != "DELETE /my-templates/"+staged.Key {
+				t.Errorf("expected the staged template to be deleted, got %s", last)
+			}
+		})
+	}
+}
+
+func TestTemplateNames(t *testing.T) {
+	tests := []struct {
+		name     string
+		endpoint string
+		region   string
+		bucket   string
+		url      string
+	}{
+		{
+			name:   "region",
+			region: "eu-west-1",
+			bucket: "cdk-runner-templates-123456789012-eu-west-1",
+			url:    "https://cdk-runner-templates-123456789012-eu-west-1.s3.eu-west-1.amazonaws.com/my-stack/1704110400000000000.yaml",
+		},
+		{
+			name:   "no region",
+			bucket: "cdk-runner-templates-123456789012-",
+			url:    "https://cdk-runner-templates-123456789012-.s3.us-east-1.amazonaws.com/my-stack/1704110400000000000.yaml",
+		},
+		{
+			name:     "endpoint",
+			endpoint: "http://localhost:9000/",
+			region:   "us-east-2",
+			bucket:   "cdk-runner-templates-123456789012-us-east-2",
+			url:      "http://localhost:9000/cdk-runner-templates-123456789012-us-east-2/my-stack/1704110400000000000.yaml",
+		},
+	}
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(S3EndpointURLEnvKey, tt.endpoint)
+			c := &Client{Config: aws.Config{Region: tt.region}}
+
+			bucket := templateBucketName("123456789012", tt.region)
+			if bucket != tt.bucket {
+				t.Errorf("expected bucket %s, got %s", tt.bucket, bucket)
+			}
+			key := templateKey("my-stack", now)
+			if key != "my-stack/1704110400000000000.yaml" {
+				t.Errorf("unexpected key %s", key)
+			}
+			if url := templateURL(c, bucket, key); url != tt.url {
+				t.Errorf("expected URL %s, got %s", tt.url, url)
+			}
+		})
+	}
+}
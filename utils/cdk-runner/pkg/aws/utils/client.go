@@ -16,7 +16,13 @@ const (
 	AwsSessionEnvKey = "ACORN_EXTERNAL_ID"
 )
 
+// WaitForClientRole waits until the web identity role can be assumed. Without a web identity token, like outside
+// of Acorn, the default credentials are used as they are.
 func WaitForClientRole(ctx context.Context) error {
+	if os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE") == "" {
+		return nil
+	}
+
 	timeOutCtx, cancel := context.WithTimeout(ctx, time.Second*30)
 	defer cancel()
 
@@ -0,0 +1,348 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	ActionAdd    = "Add"
+	ActionModify = "Modify"
+	ActionRemove = "Remove"
+)
+
+// Diff is the difference between the current and the new template of a stack, section by section
+type Diff struct {
+	Resources  []Resource
+	Parameters []Entry
+	Outputs    []Entry
+	IAM        []IAM
+}
+
+// Resource is an added, modified or removed resource
+type Resource struct {
+	LogicalID    string
+	ResourceType string
+	// Action is the action of the change set, or the one derived from the templates for resources the change set
+	// does not list
+	Action string
+	// Replacement is True, False or Conditional for resources the change set modifies
+	Replacement string
+	Properties  []Property
+}
+
+// Property is a changed value of a resource, like Properties.Tags[0].Value
+type Property struct {
+	Path     string
+	Old, New any
+	// HasOld and HasNew tell an absent value from a null one
+	HasOld, HasNew bool
+}
+
+// Entry is an added, modified or removed parameter or output
+type Entry struct {
+	Name     string
+	Action   string
+	Old, New any
+}
+
+// IAM lists the policy statements and managed policies a resource gains or loses
+type IAM struct {
+	LogicalID    string
+	ResourceType string
+	Added        []string
+	Removed      []string
+}
+
+// Empty returns true if the templates do not differ
+func (d *Diff) Empty() bool {
+	return len(d.Resources) == 0 && len(d.Parameters) == 0 && len(d.Outputs) == 0 && len(d.IAM) == 0
+}
+
+type template struct {
+	Parameters map[string]any      `json:"Parameters"`
+	Outputs    map[string]any      `json:"Outputs"`
+	Resources  map[string]resource `json:"Resources"`
+}
+
+type resource map[string]any
+
+func (r resource) Type() string {
+	t, _ := r["Type"].(string)
+	return t
+}
+
+// Compute compares the current and the new template, merging in the actions and replacements of the change set
+func Compute(currentTemplate, newTemplate []byte, changes []types.Change) (*Diff, error) {
+	current, proposed := template{}, template{}
+	if err := yaml.Unmarshal(currentTemplate, &current); err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(newTemplate, &proposed); err != nil {
+		return nil, err
+	}
+
+	byID := map[string]*types.ResourceChange{}
+	for _, change := range changes {
+		if change.ResourceChange != nil {
+			byID[aws.ToString(change.ResourceChange.LogicalResourceId)] = change.ResourceChange
+		}
+	}
+
+	d := &Diff{
+		Parameters: entries(current.Parameters, proposed.Parameters),
+		Outputs:    entries(current.Outputs, proposed.Outputs),
+	}
+
+	ids := map[string]bool{}
+	for id := range current.Resources {
+		ids[id] = true
+	}
+	for id := range proposed.Resources {
+		ids[id] = true
+	}
+	for id := range byID {
+		ids[id] = true
+	}
+
+	for _, id := range sortedKeys(ids) {
+		before, hasOld := current.Resources[id]
+		after, hasNew := proposed.Resources[id]
+
+		r := Resource{LogicalID: id, ResourceType: after.Type()}
+		if r.ResourceType == "" {
+			r.ResourceType = before.Type()
+		}
+		switch {
+		case !hasOld:
+			r.Action = ActionAdd
+		case !hasNew:
+			r.Action = ActionRemove
+		default:
+			r.Properties = properties("", map[string]any(before), map[string]any(after))
+			if len(r.Properties) > 0 {
+				r.Action = ActionModify
+			}
+		}
+
+		if rc, ok := byID[id]; ok {
+			r.Action = string(rc.Action)
+			r.Replacement = string(rc.Replacement)
+			if r.ResourceType == "" {
+				r.ResourceType = aws.ToString(rc.ResourceType)
+			}
+		}
+		if r.Action != "" {
+			d.Resources = append(d.Resources, r)
+		}
+
+		if added, removed := iamChanges(before, after); len(added) > 0 || len(removed) > 0 {
+			d.IAM = append(d.IAM, IAM{LogicalID: id, ResourceType: r.ResourceType, Added: added, Removed: removed})
+		}
+	}
+	return d, nil
+}
+
+func entries(current, proposed map[string]any) []Entry {
+	names := map[string]bool{}
+	for name := range current {
+		names[name] = true
+	}
+	for name := range proposed {
+		names[name] = true
+	}
+
+	var result []Entry
+	for _, name := range sortedKeys(names) {
+		before, hasOld := current[name]
+		after, hasNew := proposed[name]
+		switch {
+		case !hasOld:
+			result = append(result, Entry{Name: name, Action: ActionAdd, New: after})
+		case !hasNew:
+			result = append(result, Entry{Name: name, Action: ActionRemove, Old: before})
+		case !reflect.DeepEqual(before, after):
+			result = append(result, Entry{Name: name, Action: ActionModify, Old: before, New: after})
+		}
+	}
+	return result
+}
+
+// properties returns the changed leaf values below the path. Lists of different lengths are compared as a whole.
+func properties(path string, before, after any) []Property {
+	if reflect.DeepEqual(before, after) {
+		return nil
+	}
+
+	oldMap, oldIsMap := before.(map[string]any)
+	newMap, newIsMap := after.(map[string]any)
+	if oldIsMap && newIsMap {
+		keys := map[string]bool{}
+		for k := range oldMap {
+			keys[k] = true
+		}
+		for k := range newMap {
+			keys[k] = true
+		}
+
+		var result []Property
+		for _, k := range sortedKeys(keys) {
+			childPath := k
+			if path != "" {
+				childPath = path + "." + k
+			}
+			o, hasOld := oldMap[k]
+			n, hasNew := newMap[k]
+			if !hasOld || !hasNew {
+				result = append(result, Property{Path: childPath, Old: o, New: n, HasOld: hasOld, HasNew: hasNew})
+				continue
+			}
+			result = append(result, properties(childPath, o, n)...)
+		}
+		return result
+	}
+
+	oldList, oldIsList := before.([]any)
+	newList, newIsList := after.([]any)
+	if oldIsList && newIsList && len(oldList) == len(newList) {
+		var result []Property
+		for i := range oldList {
+			result = append(result, properties(fmt.Sprintf("%s[%d]", path, i), oldList[i], newList[i])...)
+		}
+		return result
+	}
+
+	return []Property{{Path: path, Old: before, New: after, HasOld: true, HasNew: true}}
+}
+
+// iamChanges returns the policy statements and managed policies of the new resource that the current one does not
+// have, and the other way around
+func iamChanges(before, after resource) (added, removed []string) {
+	oldSet, newSet := map[string]bool{}, map[string]bool{}
+	collectIAM(before["Properties"], oldSet)
+	collectIAM(after["Properties"], newSet)
+
+	for s := range newSet {
+		if !oldSet[s] {
+			added = append(added, s)
+		}
+	}
+	for s := range oldSet {
+		if !newSet[s] {
+			removed = append(removed, s)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
+
+// collectIAM finds the statements of the policy documents and the managed policy ARNs in the properties
+func collectIAM(v any, set map[string]bool) {
+	switch v := v.(type) {
+	case map[string]any:
+		if statements, ok := v["Statement"]; ok {
+			list, ok := statements.([]any)
+			if !ok {
+				list = []any{statements}
+			}
+			for _, s := range list {
+				set[statement(s)] = true
+			}
+			return
+		}
+		for k, child := range v {
+			if k == "ManagedPolicyArns" {
+				if arns, ok := child.([]any); ok {
+					for _, arn := range arns {
+						set["managed policy "+value(arn)] = true
+					}
+					continue
+				}
+			}
+			collectIAM(child, set)
+		}
+	case []any:
+		for _, child := range v {
+			collectIAM(child, set)
+		}
+	}
+}
+
+// statement describes a policy statement on one line, like Allow sqs:SendMessage on "arn:aws:sqs:..."
+func statement(s any) string {
+	m, ok := s.(map[string]any)
+	if !ok {
+		return value(s)
+	}
+
+	effect, ok := m["Effect"].(string)
+	if !ok {
+		effect = value(m["Effect"])
+	}
+	parts := []string{effect}
+	for _, key := range []string{"Action", "NotAction"} {
+		if v, ok := m[key]; ok {
+			prefix := ""
+			if key == "NotAction" {
+				prefix = "all but "
+			}
+			parts = append(parts, prefix+strings.Join(stringList(v), ", "))
+		}
+	}
+	for _, key := range []string{"Resource", "NotResource", "Principal", "NotPrincipal", "Condition"} {
+		if v, ok := m[key]; ok {
+			parts = append(parts, map[string]string{
+				"Resource":     "on",
+				"NotResource":  "on all but",
+				"Principal":    "for",
+				"NotPrincipal": "for all but",
+				"Condition":    "when",
+			}[key]+" "+value(v))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func stringList(v any) []string {
+	switch v := v.(type) {
+	case string:
+		return []string{v}
+	case []any:
+		result := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				result = append(result, s)
+			} else {
+				result = append(result, value(item))
+			}
+		}
+		return result
+	}
+	return []string{value(v)}
+}
+
+// value formats a template value as compact JSON
+func value(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+func sortedKeys(m map[string]bool) []string {
+	result := make([]string, 0, len(m))
+	for k := range m {
+		result = append(result, k)
+	}
+	sort.Strings(result)
+	return result
+}
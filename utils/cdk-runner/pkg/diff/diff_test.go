@@ -0,0 +1,157 @@
+package diff
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+)
+
+const currentTemplate = `
+Parameters:
+  Size:
+    Type: Number
+    Default: 1
+Resources:
+  Queue:
+    Type: AWS::SQS::Queue
+    Properties:
+      QueueName: my-queue
+      Tags:
+        - Key: team
+          Value: a
+  Role:
+    Type: AWS::IAM::Role
+    Properties:
+      ManagedPolicyArns:
+        - arn:aws:iam::aws:policy/ReadOnlyAccess
+      Policies:
+        - PolicyName: queue
+          PolicyDocument:
+            Statement:
+              - Effect: Allow
+                Action: sqs:ReceiveMessage
+                Resource: "*"
+  Topic:
+    Type: AWS::SNS::Topic
+Outputs:
+  QueueURL:
+    Value: url
+`
+
+const newTemplate = `
+Parameters:
+  Size:
+    Type: Number
+    Default: 2
+  Name:
+    Type: String
+Resources:
+  Queue:
+    Type: AWS::SQS::Queue
+    Properties:
+      QueueName: my-renamed-queue
+      Tags:
+        - Key: team
+          Value: b
+  Role:
+    Type: AWS::IAM::Role
+    Properties:
+      Policies:
+        - PolicyName: queue
+          PolicyDocument:
+            Statement:
+              - Effect: Allow
+                Action:
+                  - sqs:ReceiveMessage
+                  - sqs:DeleteMessage
+                Resource: "*"
+  Bucket:
+    Type: AWS::S3::Bucket
+`
+
+func TestCompute(t *testing.T) {
+	changes := []types.Change{
+		{ResourceChange: &types.ResourceChange{
+			LogicalResourceId: aws.String("Queue"),
+			ResourceType:      aws.String("AWS::SQS::Queue"),
+			Action:            types.ChangeActionModify,
+			Replacement:       types.ReplacementTrue,
+		}},
+	}
+
+	d, err := Compute([]byte(currentTemplate), []byte(newTemplate), changes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resources := []Resource{
+		{LogicalID: "Bucket", ResourceType: "AWS::S3::Bucket", Action: ActionAdd},
+		{
+			LogicalID:    "Queue",
+			ResourceType: "AWS::SQS::Queue",
+			Action:       ActionModify,
+			Replacement:  "True",
+			Properties: []Property{
+				{Path: "Properties.QueueName", Old: "my-queue", New: "my-renamed-queue", HasOld: true, HasNew: true},
+				{Path: "Properties.Tags[0].Value", Old: "a", New: "b", HasOld: true, HasNew: true},
+			},
+		},
+		{
+			LogicalID:    "Role",
+			ResourceType: "AWS::IAM::Role",
+			Action:       ActionModify,
+			Properties: []Property{
+				{Path: "Properties.ManagedPolicyArns", Old: []any{"arn:aws:iam::aws:policy/ReadOnlyAccess"}, HasOld: true},
+				{Path: "Properties.Policies[0].PolicyDocument.Statement[0].Action", Old: "sqs:ReceiveMessage", New: []any{"sqs:ReceiveMessage", "sqs:DeleteMessage"}, HasOld: true, HasNew: true},
+			},
+		},
+		{LogicalID: "Topic", ResourceType: "AWS::SNS::Topic", Action: ActionRemove},
+	}
+	if !reflect.DeepEqual(d.Resources, resources) {
+		t.Errorf("expected resources\n%+v\ngot\n%+v", resources, d.Resources)
+	}
+
+	parameters := []Entry{
+		{Name: "Name", Action: ActionAdd, New: map[string]any{"Type": "String"}},
+		{Name: "Size", Action: ActionModify, Old: map[string]any{"Type": "Number", "Default": float64(1)}, New: map[string]any{"Type": "Number", "Default": float64(2)}},
+	}
+	if !reflect.DeepEqual(d.Parameters, parameters) {
+		t.Errorf("expected parameters\n%+v\ngot\n%+v", parameters, d.Parameters)
+	}
+
+	outputs := []Entry{{Name: "QueueURL", Action: ActionRemove, Old: map[string]any{"Value": "url"}}}
+	if !reflect.DeepEqual(d.Outputs, outputs) {
+		t.Errorf("expected outputs\n%+v\ngot\n%+v", outputs, d.Outputs)
+	}
+
+	iam := []IAM{{
+		LogicalID:    "Role",
+		ResourceType: "AWS::IAM::Role",
+		Added:        []string{`Allow sqs:ReceiveMessage, sqs:DeleteMessage on "*"`},
+		Removed:      []string{`Allow sqs:ReceiveMessage on "*"`, `managed policy "arn:aws:iam::aws:policy/ReadOnlyAccess"`},
+	}}
+	if !reflect.DeepEqual(d.IAM, iam) {
+		t.Errorf("expected IAM\n%+v\ngot\n%+v", iam, d.IAM)
+	}
+}
+
+func TestComputeEmpty(t *testing.T) {
+	d, err := Compute([]byte(currentTemplate), []byte(currentTemplate), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !d.Empty() {
+		t.Errorf("expected no differences, got %+v", d)
+	}
+
+	// A new stack has no current template
+	d, err = Compute(nil, []byte(newTemplate), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Resources) != 3 || d.Resources[0].Action != ActionAdd {
+		t.Errorf("expected the resources to be added, got %+v", d.Resources)
+	}
+}
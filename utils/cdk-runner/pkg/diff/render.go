@@ -0,0 +1,242 @@
+package diff
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	FormatText     = "text"
+	FormatMarkdown = "markdown"
+
+	// maxValueLength is the length values are cut off at, so large documents do not drown the diff
+	maxValueLength = 200
+
+	colorReset  = "\033[0m"
+	colorRed    = "\033[31m"
+	colorGreen  = "\033[32m"
+	colorYellow = "\033[33m"
+	colorBold   = "\033[1m"
+)
+
+// Render writes the diff in the format, text or markdown. Text is colored when w is a terminal and NO_COLOR is
+// not set.
+func (d *Diff) Render(w io.Writer, format string) error {
+	switch format {
+	case "", FormatText:
+		return d.Text(w, useColor(w))
+	case FormatMarkdown:
+		return d.Markdown(w)
+	}
+	return fmt.Errorf("unknown diff format %q, use %s or %s", format, FormatText, FormatMarkdown)
+}
+
+func useColor(w io.Writer) bool {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Text writes the diff as text, with + for additions, - for removals and ~ for modifications
+func (d *Diff) Text(w io.Writer, color bool) error {
+	p := &printer{w: w, color: color}
+
+	if d.Empty() {
+		p.line("", "No changes")
+		return p.err
+	}
+
+	if len(d.Resources) > 0 {
+		p.line(colorBold, "Resources")
+		for _, r := range d.Resources {
+			p.line(actionColor(r.Action), "  %s %s %s%s", symbol(r.Action), r.LogicalID, r.ResourceType, p.replacement(r.Replacement))
+			for _, prop := range r.Properties {
+				switch {
+				case !prop.HasOld:
+					p.line(colorGreen, "      + %s: %s", prop.Path, short(prop.New))
+				case !prop.HasNew:
+					p.line(colorRed, "      - %s: %s", prop.Path, short(prop.Old))
+				default:
+					p.line("", "      ~ %s: %s => %s", prop.Path, short(prop.Old), short(prop.New))
+				}
+			}
+		}
+	}
+
+	for _, section := range []struct {
+		name    string
+		entries []Entry
+	}{{"Parameters", d.Parameters}, {"Outputs", d.Outputs}} {
+		if len(section.entries) == 0 {
+			continue
+		}
+		p.line(colorBold, section.name)
+		for _, e := range section.entries {
+			switch e.Action {
+			case ActionAdd:
+				p.line(colorGreen, "  + %s: %s", e.Name, short(e.New))
+			case ActionRemove:
+				p.line(colorRed, "  - %s: %s", e.Name, short(e.Old))
+			default:
+				p.line(colorYellow, "  ~ %s: %s => %s", e.Name, short(e.Old), short(e.New))
+			}
+		}
+	}
+
+	if len(d.IAM) > 0 {
+		p.line(colorBold, "IAM")
+		for _, iam := range d.IAM {
+			p.line("", "  %s %s", iam.LogicalID, iam.ResourceType)
+			for _, s := range iam.Added {
+				p.line(colorGreen, "      + %s", s)
+			}
+			for _, s := range iam.Removed {
+				p.line(colorRed, "      - %s", s)
+			}
+		}
+	}
+	return p.err
+}
+
+// Markdown writes the diff as Markdown, for pull request comments and job summaries
+func (d *Diff) Markdown(w io.Writer) error {
+	p := &printer{w: w}
+
+	if d.Empty() {
+		p.line("", "No changes")
+		return p.err
+	}
+
+	if len(d.Resources) > 0 {
+		p.line("", "### Resources\n")
+		p.line("", "| Action | Logical ID | Type | Replacement |")
+		p.line("", "|--------|------------|------|-------------|")
+		for _, r := range d.Resources {
+			p.line("", "| %s | %s | %s | %s |", r.Action, r.LogicalID, r.ResourceType, r.Replacement)
+		}
+
+		for _, r := range d.Resources {
+			if len(r.Properties) == 0 {
+				continue
+			}
+			p.line("", "\n#### %s\n", r.LogicalID)
+			p.line("", "```diff")
+			for _, prop := range r.Properties {
+				if prop.HasOld {
+					p.line("", "- %s: %s", prop.Path, short(prop.Old))
+				}
+				if prop.HasNew {
+					p.line("", "+ %s: %s", prop.Path, short(prop.New))
+				}
+			}
+			p.line("", "```")
+		}
+	}
+
+	for _, section := range []struct {
+		name    string
+		entries []Entry
+	}{{"Parameters", d.Parameters}, {"Outputs", d.Outputs}} {
+		if len(section.entries) == 0 {
+			continue
+		}
+		p.line("", "\n### %s\n", section.name)
+		p.line("", "```diff")
+		for _, e := range section.entries {
+			if e.Action != ActionAdd {
+				p.line("", "- %s: %s", e.Name, short(e.Old))
+			}
+			if e.Action != ActionRemove {
+				p.line("", "+ %s: %s", e.Name, short(e.New))
+			}
+		}
+		p.line("", "```")
+	}
+
+	if len(d.IAM) > 0 {
+		p.line("", "\n### IAM\n")
+		for _, iam := range d.IAM {
+			p.line("", "#### %s (%s)\n", iam.LogicalID, iam.ResourceType)
+			p.line("", "```diff")
+			for _, s := range iam.Added {
+				p.line("", "+ %s", s)
+			}
+			for _, s := range iam.Removed {
+				p.line("", "- %s", s)
+			}
+			p.line("", "```")
+		}
+	}
+	return p.err
+}
+
+// printer writes lines, keeping the first error
+type printer struct {
+	w     io.Writer
+	color bool
+	err   error
+}
+
+func (p *printer) line(color, format string, args ...any) {
+	if p.err != nil {
+		return
+	}
+	s := fmt.Sprintf(format, args...)
+	if p.color && color != "" {
+		s = color + s + colorReset
+	}
+	_, p.err = fmt.Fprintln(p.w, s)
+}
+
+func (p *printer) replacement(replacement string) string {
+	var s string
+	switch replacement {
+	case "True":
+		s = " (replacement)"
+	case "Conditional":
+		s = " (may be replaced)"
+	default:
+		return ""
+	}
+	if p.color {
+		return colorBold + colorRed + s + colorReset
+	}
+	return s
+}
+
+func symbol(action string) string {
+	switch action {
+	case ActionAdd:
+		return "+"
+	case ActionRemove:
+		return "-"
+	}
+	return "~"
+}
+
+func actionColor(action string) string {
+	switch action {
+	case ActionAdd:
+		return colorGreen
+	case ActionRemove:
+		return colorRed
+	}
+	return colorYellow
+}
+
+// short cuts the value off at maxValueLength runes, without splitting a multi-byte character
+func short(v any) string {
+	s := value(v)
+	if r := []rune(s); len(r) > maxValueLength {
+		s = string(r[:maxValueLength]) + "..."
+	}
+	return strings.ReplaceAll(s, "\n", " ")
+}
@@ -0,0 +1,165 @@
+package diff
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+var testDiff = &Diff{
+	Resources: []Resource{
+		{
+			LogicalID:    "Queue",
+			ResourceType: "AWS::SQS::Queue",
+			Action:       ActionModify,
+			Replacement:  "True",
+			Properties: []Property{
+				{Path: "Properties.QueueName", Old: "a", New: "b", HasOld: true, HasNew: true},
+				{Path: "Properties.DelaySeconds", New: 5, HasNew: true},
+			},
+		},
+		{LogicalID: "Topic", ResourceType: "AWS::SNS::Topic", Action: ActionRemove},
+	},
+	Outputs: []Entry{{Name: "QueueURL", Action: ActionAdd, New: "url"}},
+	IAM: []IAM{{
+		LogicalID:    "Role",
+		ResourceType: "AWS::IAM::Role",
+		Added:        []string{`Allow s3:GetObject on "*"`},
+	}},
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name        string
+		diff        *Diff
+		format      string
+		want        string
+		errContains string
+	}{
+		{
+			name:   "no changes",
+			diff:   &Diff{},
+			format: FormatText,
+			want:   "No changes\n",
+		},
+		{
+			name:   "no changes in markdown",
+			diff:   &Diff{},
+			format: FormatMarkdown,
+			want:   "No changes\n",
+		},
+		{
+			name:   "text",
+			diff:   testDiff,
+			format: "",
+			want: `Resources
+  ~ Queue AWS::SQS::Queue (replacement)
+      ~ Properties.QueueName: "a" => "b"
+      + Properties.DelaySeconds: 5
+  - Topic AWS::SNS::Topic
+Outputs
+  + QueueURL: "url"
+IAM
+  Role AWS::IAM::Role
+      + Allow s3:GetObject on "*"
+`,
+		},
+		{
+			name:   "markdown",
+			diff:   testDiff,
+			format: FormatMarkdown,
+			want: "### Resources\n\n" +
+				"| Action | Logical ID | Type | Replacement |\n" +
+				"|--------|------------|------|-------------|\n" +
+				"| Modify | Queue | AWS::SQS::Queue | True |\n" +
+				"| Remove | Topic | AWS::SNS::Topic |  |\n" +
+				"\n#### Queue\n\n" +
+				"```diff\n" +
+				"- Properties.QueueName: \"a\"\n" +
+				"+ Properties.QueueName: \"b\"\n" +
+				"+ Properties.DelaySeconds: 5\n" +
+				"```\n" +
+				"\n### Outputs\n\n" +
+				"```diff\n" +
+				"+ QueueURL: \"url\"\n" +
+				"```\n" +
+				"\n### IAM\n\n" +
+				"#### Role (AWS::IAM::Role)\n\n" +
+				"```diff\n" +
+				"+ Allow s3:GetObject on \"*\"\n" +
+				"```\n",
+		},
+		{
+			name:        "unknown format",
+			diff:        testDiff,
+			format:      "html",
+			errContains: `unknown diff format "html"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := tt.diff.Render(&buf, tt.format)
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Fatalf("expected error to contain %q, got %v", tt.errContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("expected\n%s\ngot\n%s", tt.want, buf.String())
+			}
+		})
+	}
+}
+
+func TestTextColor(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testDiff.Text(&buf, true); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), colorRed+"  - Topic AWS::SNS::Topic"+colorReset) {
+		t.Errorf("expected the removed resource in red, got %q", buf.String())
+	}
+}
+
+func TestShort(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+		want  string
+	}{
+		{
+			name:  "short value",
+			value: "a\nb",
+			want:  `"a\nb"`,
+		},
+		{
+			name:  "long value",
+			value: strings.Repeat("a", 300),
+			want:  `"` + strings.Repeat("a", maxValueLength-1) + "...",
+		},
+		{
+			name:  "multi-byte runes",
+			value: strings.Repeat("é", 300),
+			want:  `"` + strings.Repeat("é", maxValueLength-1) + "...",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := short(tt.value)
+			if got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("expected valid UTF-8, got %q", got)
+			}
+		})
+	}
+}
@@ -1,60 +1,222 @@
 package hooks
 
 import (
+	"bufio"
 	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
 
 	"github.com/acorn-io/aws/utils/cdk-runner/pkg/utils"
 	"github.com/sirupsen/logrus"
 )
 
+// Phase is a point of the run that hooks can run at
+type Phase string
+
 const (
-	PreChangeSetApplyHookExecutable = "/app/hooks/pre-change-set-apply"
-	DryRunHookExecutable            = "/app/hooks/dry-run"
+	PreSynth          Phase = "pre-synth"
+	PostSynth         Phase = "post-synth"
+	PreChangeSetApply Phase = "pre-change-set-apply"
+	DryRun            Phase = "dry-run"
+	PostApply         Phase = "post-apply"
+	PreDelete         Phase = "pre-delete"
+	PostDelete        Phase = "post-delete"
+	OnFailure         Phase = "on-failure"
 )
 
 var (
-	currentTemplate = "/app/current-template.yaml"
-	newTemplate     = "/app/cfn.yaml"
-	changeSet       = "/app/change-set.json"
+	// Dir is the directory of the hooks
+	Dir = "/app/hooks"
+	// Timeout is the time each hook may run, unless the hook declares its own
+	Timeout = 10 * time.Minute
+
+	// timeoutDirective declares the timeout of a hook in one of its first lines, ex. "# cdk-runner-hook-timeout: 30m"
+	timeoutDirective = regexp.MustCompile(`cdk-runner-hook-timeout:\s*(\S+)`)
 )
 
-// RunChangesetHook runs the given executable as a hook that receives the currentTemplate, newTemplate, and changeset as args
-// may return an error
-func RunChangesetHook(executable string) error {
-	// Check exec file exists and is executable
-	info, err := os.Stat(executable)
+// timeoutDirectiveLines is how many lines of a hook are searched for the timeout directive
+const timeoutDirectiveLines = 10
+
+// Context is the JSON document hooks receive on stdin
+type Context struct {
+	Phase     Phase  `json:"phase"`
+	StackName string `json:"stackName"`
+	Event     string `json:"event"`
+	DryRun    bool   `json:"dryRun"`
+	// Outputs of the stack, once it is deployed
+	Outputs map[string]string `json:"outputs,omitempty"`
+	// CurrentTemplate, NewTemplate and ChangeSet are the absolute paths of the template and change set files, once
+	// the change set is created
+	CurrentTemplate string `json:"currentTemplate,omitempty"`
+	NewTemplate     string `json:"newTemplate,omitempty"`
+	ChangeSet       string `json:"changeSet,omitempty"`
+	// Error the run failed with, for the on-failure hooks
+	Error string `json:"error,omitempty"`
+}
+
+// Executables returns the hooks of the phase in the order they run: <phase> in the hooks directory, then the files
+// of <phase>.d in lexical order. Missing and non-executable files are skipped.
+func Executables(phase Phase) ([]string, error) {
+	var result []string
+
+	single := filepath.Join(Dir, string(phase))
+	if ok, err := isExecutable(single); err != nil {
+		return nil, err
+	} else if ok {
+		result = append(result, single)
+	}
+
+	dir := single + ".d"
+	entries, err := os.ReadDir(dir)
 	if os.IsNotExist(err) {
-		logrus.Infof("no %s hook found at %s", filepath.Base(executable), executable)
-		return nil
+		return result, nil
 	} else if err != nil {
-		return err
+		return nil, err
 	}
 
-	// Check if exec file is executable
-	if info.Mode()&0111 == 0 {
-		logrus.Infof("%s hook found at %s but is not executable", filepath.Base(executable), executable)
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		executable := filepath.Join(dir, name)
+		if ok, err := isExecutable(executable); err != nil {
+			return nil, err
+		} else if ok {
+			result = append(result, executable)
+		}
+	}
+	return result, nil
+}
+
+// Run runs the hooks of the phase in order, stopping at the first one that fails. Hooks of the phases with a
+// change set also receive the paths of the current template, the new template and the change set as arguments.
+func Run(phase Phase, hctx Context) error {
+	executables, err := Executables(phase)
+	if err != nil {
+		return err
+	}
+	if len(executables) == 0 {
+		logrus.Infof("no %s hooks found in %s", phase, Dir)
 		return nil
 	}
 
-	logrus.Infof("running %s hook at %s", filepath.Base(executable), executable)
-	cmd := exec.Command(executable, currentTemplate, newTemplate, changeSet)
+	hctx.Phase = phase
+	// Hooks run in their own working directory, so they get absolute paths
+	for _, path := range []*string{&hctx.CurrentTemplate, &hctx.NewTemplate, &hctx.ChangeSet} {
+		if *path == "" {
+			continue
+		}
+		if *path, err = filepath.Abs(*path); err != nil {
+			return err
+		}
+	}
+	stdin, err := json.Marshal(hctx)
+	if err != nil {
+		return err
+	}
+
+	var args []string
+	if hctx.ChangeSet != "" {
+		args = []string{hctx.CurrentTemplate, hctx.NewTemplate, hctx.ChangeSet}
+	}
 
-	var out bytes.Buffer
+	for _, executable := range executables {
+		if err := runHook(executable, stdin, args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runHook runs the executable with the context on stdin, streaming its output to the log as it is written.
+func runHook(executable string, stdin []byte, args ...string) error {
+	timeout, err := hookTimeout(executable)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	logrus.Infof("running hook %s", executable)
+	log := logrus.WithField("hook", filepath.Base(executable))
+	stdout := log.WriterLevel(logrus.InfoLevel)
+	defer stdout.Close()
+	stderrLog := log.WriterLevel(logrus.WarnLevel)
+	defer stderrLog.Close()
+
+	// Keep stderr around for the termination log
 	var stderr bytes.Buffer
 
-	cmd.Stdout = &out
-	cmd.Stderr = &stderr
-	defer func() {
-		logrus.Info(out.String())
-		logrus.Info(stderr.String())
-	}()
+	cmd := exec.CommandContext(ctx, executable, args...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Stdout = stdout
+	cmd.Stderr = io.MultiWriter(stderrLog, &stderr)
+	cmd.WaitDelay = 10 * time.Second
 
 	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			err = fmt.Errorf("hook %s did not finish within %s", executable, timeout)
+		} else {
+			err = fmt.Errorf("hook %s failed: %w", executable, err)
+		}
 		return utils.WriteToTermLogAndError(stderr.Bytes(), err)
 	}
-
 	return nil
 }
+
+// hookTimeout returns the timeout the hook declares with the timeout directive, or Timeout when it declares none.
+// Only the first lines are searched, binaries without the directive get Timeout as well.
+func hookTimeout(executable string) (time.Duration, error) {
+	f, err := os.Open(executable)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for i := 0; i < timeoutDirectiveLines && scanner.Scan(); i++ {
+		match := timeoutDirective.FindStringSubmatch(scanner.Text())
+		if match == nil {
+			continue
+		}
+		timeout, err := time.ParseDuration(match[1])
+		if err != nil {
+			return 0, fmt.Errorf("hook %s declares an invalid timeout: %w", executable, err)
+		}
+		if timeout <= 0 {
+			return 0, fmt.Errorf("hook %s declares a timeout of %s, it must be positive", executable, timeout)
+		}
+		return timeout, nil
+	}
+	return Timeout, nil
+}
+
+func isExecutable(name string) (bool, error) {
+	info, err := os.Stat(name)
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	if info.IsDir() {
+		return false, nil
+	}
+	if info.Mode()&0111 == 0 {
+		logrus.Infof("hook found at %s but is not executable", name)
+		return false, nil
+	}
+	return true, nil
+}
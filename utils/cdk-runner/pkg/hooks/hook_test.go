@@ -0,0 +1,235 @@
+package hooks
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExecutables(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]os.FileMode
+		want  []string
+	}{
+		{
+			name: "no hooks",
+		},
+		{
+			name:  "single hook",
+			files: map[string]os.FileMode{"post-apply": 0755},
+			want:  []string{"post-apply"},
+		},
+		{
+			name: "single hook first, then the directory in lexical order",
+			files: map[string]os.FileMode{
+				"post-apply.d/20-notify": 0755,
+				"post-apply.d/10-smoke":  0755,
+				"post-apply":             0755,
+			},
+			want: []string{"post-apply", "post-apply.d/10-smoke", "post-apply.d/20-notify"},
+		},
+		{
+			name: "non-executable files are skipped",
+			files: map[string]os.FileMode{
+				"post-apply":             0644,
+				"post-apply.d/10-smoke":  0755,
+				"post-apply.d/README.md": 0644,
+			},
+			want: []string{"post-apply.d/10-smoke"},
+		},
+		{
+			name: "other phases are skipped",
+			files: map[string]os.FileMode{
+				"pre-delete":            0755,
+				"pre-delete.d/10-drain": 0755,
+				"post-apply.d/10-smoke": 0755,
+			},
+			want: []string{"post-apply.d/10-smoke"},
+		},
+		{
+			name: "directories are skipped",
+			files: map[string]os.FileMode{
+				"post-apply.d/nested/10-smoke": 0755,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, mode := range tt.files {
+				path := filepath.Join(dir, name)
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte("#!/bin/sh\n"), mode); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			oldDir := Dir
+			Dir = dir
+			defer func() { Dir = oldDir }()
+
+			executables, err := Executables(PostApply)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, e := range executables {
+				got = append(got, strings.TrimPrefix(e, dir+"/"))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out")
+	// Each hook appends its name, arguments and stdin to the out file
+	script := "#!/bin/sh\necho \"$(basename $0) $*\" >> " + out + "\ncat >> " + out + "\necho >> " + out + "\n"
+	if err := os.MkdirAll(filepath.Join(dir, "pre-change-set-apply.d"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"pre-change-set-apply", "pre-change-set-apply.d/10-check"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(script), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	oldDir := Dir
+	Dir = dir
+	defer func() { Dir = oldDir }()
+
+	err := Run(PreChangeSetApply, Context{
+		StackName:       "my-stack",
+		Event:           "update",
+		CurrentTemplate: "current.yaml",
+		NewTemplate:     "new.yaml",
+		ChangeSet:       "change-set.json",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("expected both hooks to run, got %q", data)
+	}
+	// The paths are passed as absolute paths, the hooks may run in another directory
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	current, next, changeSet := filepath.Join(wd, "current.yaml"), filepath.Join(wd, "new.yaml"), filepath.Join(wd, "change-set.json")
+	for i, name := range []string{"pre-change-set-apply", "10-check"} {
+		if want := name + " " + current + " " + next + " " + changeSet; lines[2*i] != want {
+			t.Errorf("expected %q, got %q", want, lines[2*i])
+		}
+		var hctx Context
+		if err := json.Unmarshal([]byte(lines[2*i+1]), &hctx); err != nil {
+			t.Fatal(err)
+		}
+		if hctx.Phase != PreChangeSetApply || hctx.StackName != "my-stack" || hctx.NewTemplate != next || hctx.ChangeSet != changeSet {
+			t.Errorf("unexpected context %+v", hctx)
+		}
+	}
+}
+
+func TestHookTimeout(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		want        time.Duration
+		errContains string
+	}{
+		{
+			name:    "default",
+			content: "#!/bin/sh\necho hello\n",
+			want:    Timeout,
+		},
+		{
+			name:    "declared",
+			content: "#!/bin/sh\n# Runs the smoke tests\n# cdk-runner-hook-timeout: 30m\n",
+			want:    30 * time.Minute,
+		},
+		{
+			name:    "declared after the first lines",
+			content: "#!/bin/sh\n" + strings.Repeat("echo\n", timeoutDirectiveLines) + "# cdk-runner-hook-timeout: 30m\n",
+			want:    Timeout,
+		},
+		{
+			name:    "binary",
+			content: "\x7fELF" + strings.Repeat("\x00", 1<<17),
+			want:    Timeout,
+		},
+		{
+			name:        "invalid",
+			content:     "#!/bin/sh\n# cdk-runner-hook-timeout: half an hour\n",
+			errContains: "declares an invalid timeout",
+		},
+		{
+			name:        "not positive",
+			content:     "#!/bin/sh\n# cdk-runner-hook-timeout: 0s\n",
+			errContains: "it must be positive",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hook := filepath.Join(t.TempDir(), "post-apply")
+			if err := os.WriteFile(hook, []byte(tt.content), 0755); err != nil {
+				t.Fatal(err)
+			}
+			got, err := hookTimeout(hook)
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Fatalf("expected error to contain %q, got %v", tt.errContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRunTimeout(t *testing.T) {
+	dir := t.TempDir()
+	// The second hook declares a shorter timeout than the first one is given
+	if err := os.WriteFile(filepath.Join(dir, "post-apply"), []byte("#!/bin/sh\nsleep 0.2\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "post-apply.d"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	slow := filepath.Join(dir, "post-apply.d", "10-slow")
+	if err := os.WriteFile(slow, []byte("#!/bin/sh\n# cdk-runner-hook-timeout: 100ms\nexec sleep 5\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	oldDir, oldTimeout := Dir, Timeout
+	Dir, Timeout = dir, time.Minute
+	defer func() { Dir, Timeout = oldDir, oldTimeout }()
+
+	err := Run(PostApply, Context{StackName: "my-stack"})
+	if want := "hook " + slow + " did not finish within 100ms"; err == nil || err.Error() != want {
+		t.Errorf("expected %q, got %v", want, err)
+	}
+}
@@ -0,0 +1,309 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	"github.com/google/cel-go/cel"
+	"sigs.k8s.io/yaml"
+)
+
+// DefaultDir is the directory the policy files are read from
+var DefaultDir = "/app/policies"
+
+const (
+	// DirEnvKey overrides the directory the policy files are read from
+	DirEnvKey = "POLICY_DIR"
+
+	SeverityWarn = "warn"
+	SeverityDeny = "deny"
+
+	LanguageCEL = "cel"
+)
+
+// File is a policy file, holding any number of rules
+type File struct {
+	Rules []Rule `json:"rules"`
+}
+
+// Rule is a check every resource of the stack must pass
+type Rule struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Severity is warn to only report violations, or deny to also fail the run. Defaults to deny.
+	Severity string `json:"severity,omitempty"`
+	// Language of the expression, only cel is supported
+	Language string `json:"language,omitempty"`
+	// ResourceTypes limits the rule to resources of these types, all resources are checked when it is empty
+	ResourceTypes []string `json:"resourceTypes,omitempty"`
+	// Expression must evaluate to true for a resource that complies with the rule
+	Expression string `json:"expression"`
+	// Message describes the violation, defaults to the description of the rule
+	Message string `json:"message,omitempty"`
+
+	file    string
+	program cel.Program
+}
+
+// Violation is a resource that does not comply with a rule
+type Violation struct {
+	Rule         string `json:"rule"`
+	Severity     string `json:"severity"`
+	LogicalID    string `json:"logicalId"`
+	ResourceType string `json:"resourceType"`
+	Message      string `json:"message"`
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %s %s: %s", v.Rule, v.ResourceType, v.LogicalID, v.Message)
+}
+
+// Dir returns the directory the policy files are read from
+func Dir() string {
+	if dir := os.Getenv(DirEnvKey); dir != "" {
+		return dir
+	}
+	return DefaultDir
+}
+
+var env, envErr = cel.NewEnv(
+	cel.Variable("logicalId", cel.StringType),
+	cel.Variable("resource", cel.DynType),
+	cel.Variable("current", cel.DynType),
+	cel.Variable("change", cel.DynType),
+	cel.Variable("template", cel.DynType),
+	cel.Variable("currentTemplate", cel.DynType),
+)
+
+// Load reads and compiles the rules of the .yaml, .yml and .json files in the directory, in lexical order of the
+// file names. A missing directory has no rules.
+func Load(dir string) ([]Rule, error) {
+	if envErr != nil {
+		return nil, envErr
+	}
+
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var rules []Rule
+	for _, entry := range entries {
+		switch filepath.Ext(entry.Name()) {
+		case ".yaml", ".yml", ".json":
+		default:
+			continue
+		}
+		if entry.IsDir() {
+			continue
+		}
+
+		name := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+
+		var file File
+		if err := yaml.UnmarshalStrict(data, &file); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		for _, rule := range file.Rules {
+			rule.file = name
+			if err := rule.compile(); err != nil {
+				return nil, err
+			}
+			rules = append(rules, rule)
+		}
+	}
+	return rules, nil
+}
+
+func (r *Rule) compile() error {
+	if r.Name == "" {
+		return fmt.Errorf("%s: rule without a name", r.file)
+	}
+	switch r.Severity {
+	case "":
+		r.Severity = SeverityDeny
+	case SeverityWarn, SeverityDeny:
+	default:
+		return fmt.Errorf("%s: rule %s: severity %q is not %s or %s", r.file, r.Name, r.Severity, SeverityWarn, SeverityDeny)
+	}
+	if r.Language != "" && r.Language != LanguageCEL {
+		return fmt.Errorf("%s: rule %s: language %q is not supported, use %s", r.file, r.Name, r.Language, LanguageCEL)
+	}
+	if r.Message == "" {
+		r.Message = r.Description
+	}
+	if r.Message == "" {
+		r.Message = "the resource does not comply with the rule"
+	}
+
+	ast, issues := env.Compile(r.Expression)
+	if issues != nil && issues.Err() != nil {
+		return fmt.Errorf("%s: rule %s: %w", r.file, r.Name, issues.Err())
+	}
+	if ast.OutputType() != cel.BoolType && ast.OutputType() != cel.DynType {
+		return fmt.Errorf("%s: rule %s: expression returns %s, not bool", r.file, r.Name, ast.OutputType())
+	}
+
+	program, err := env.Program(ast)
+	if err != nil {
+		return fmt.Errorf("%s: rule %s: %w", r.file, r.Name, err)
+	}
+	r.program = program
+	return nil
+}
+
+// Evaluate checks every resource of the new template, and the resources the change set removes, against the rules.
+// An expression that fails to evaluate, like one reading a property the resource does not set, is a violation.
+func Evaluate(rules []Rule, changes []types.Change, currentTemplate, newTemplate []byte) ([]Violation, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	current, err := parseTemplate(currentTemplate)
+	if err != nil {
+		return nil, err
+	}
+	proposed, err := parseTemplate(newTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	changesByID, err := changeMaps(changes)
+	if err != nil {
+		return nil, err
+	}
+
+	currentResources, _ := current["Resources"].(map[string]any)
+	proposedResources, _ := proposed["Resources"].(map[string]any)
+
+	logicalIDs := map[string]bool{}
+	for id := range proposedResources {
+		logicalIDs[id] = true
+	}
+	for id := range changesByID {
+		logicalIDs[id] = true
+	}
+	sorted := make([]string, 0, len(logicalIDs))
+	for id := range logicalIDs {
+		sorted = append(sorted, id)
+	}
+	sort.Strings(sorted)
+
+	var violations []Violation
+	for _, id := range sorted {
+		resource := mapOrEmpty(proposedResources[id])
+		currentResource := mapOrEmpty(currentResources[id])
+		change := mapOrEmpty(changesByID[id])
+
+		resourceType, _ := resource["Type"].(string)
+		if resourceType == "" {
+			resourceType, _ = currentResource["Type"].(string)
+		}
+
+		for _, rule := range rules {
+			if len(rule.ResourceTypes) > 0 && !contains(rule.ResourceTypes, resourceType) {
+				continue
+			}
+
+			message := rule.Message
+			out, _, err := rule.program.Eval(map[string]any{
+				"logicalId":       id,
+				"resource":        resource,
+				"current":         currentResource,
+				"change":          change,
+				"template":        proposed,
+				"currentTemplate": current,
+			})
+			if err == nil && out.Value() == true {
+				continue
+			} else if err != nil {
+				message = strings.TrimSpace(fmt.Sprintf("%s (%v)", message, err))
+			} else if _, ok := out.Value().(bool); !ok {
+				message = strings.TrimSpace(fmt.Sprintf("%s (expression returned %v, not a bool)", message, out.Value()))
+			}
+
+			violations = append(violations, Violation{
+				Rule:         rule.Name,
+				Severity:     rule.Severity,
+				LogicalID:    id,
+				ResourceType: resourceType,
+				Message:      message,
+			})
+		}
+	}
+	return violations, nil
+}
+
+// Denied returns an error listing the violations of rules with the deny severity, if there are any
+func Denied(violations []Violation) error {
+	var denied []string
+	for _, v := range violations {
+		if v.Severity == SeverityDeny {
+			denied = append(denied, v.String())
+		}
+	}
+	if len(denied) > 0 {
+		return fmt.Errorf("the stack violates %d policy rule(s): %s", len(denied), strings.Join(denied, "; "))
+	}
+	return nil
+}
+
+func parseTemplate(template []byte) (map[string]any, error) {
+	result := map[string]any{}
+	if err := yaml.Unmarshal(template, &result); err != nil {
+		return nil, err
+	}
+	if result == nil {
+		result = map[string]any{}
+	}
+	return result, nil
+}
+
+// changeMaps returns the resource changes of the change set as they are written to the change set file, by logical
+// ID
+func changeMaps(changes []types.Change) (map[string]any, error) {
+	result := map[string]any{}
+	for _, change := range changes {
+		if change.ResourceChange == nil {
+			continue
+		}
+		data, err := json.Marshal(change.ResourceChange)
+		if err != nil {
+			return nil, err
+		}
+		m := map[string]any{}
+		if err := json.Unmarshal(data, &m); err != nil {
+			return nil, err
+		}
+		result[aws.ToString(change.ResourceChange.LogicalResourceId)] = m
+	}
+	return result, nil
+}
+
+func mapOrEmpty(v any) map[string]any {
+	if m, ok := v.(map[string]any); ok {
+		return m
+	}
+	return map[string]any{}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
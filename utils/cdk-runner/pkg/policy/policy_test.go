@@ -0,0 +1,226 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name        string
+		files       map[string]string
+		want        []string
+		errContains string
+	}{
+		{
+			name: "files in lexical order",
+			files: map[string]string{
+				"b.yml":     "rules:\n- name: second\n  expression: 'true'\n",
+				"a.yaml":    "rules:\n- name: first\n  expression: 'true'\n",
+				"c.json":    `{"rules": [{"name": "third", "expression": "true"}]}`,
+				"notes.txt": "rules:\n- name: ignored\n",
+			},
+			want: []string{"first", "second", "third"},
+		},
+		{
+			name:        "unknown field",
+			files:       map[string]string{"a.yaml": "rules:\n- name: typo\n  expresion: 'true'\n"},
+			errContains: `unknown field "expresion"`,
+		},
+		{
+			name:        "rule without a name",
+			files:       map[string]string{"a.yaml": "rules:\n- expression: 'true'\n"},
+			errContains: "rule without a name",
+		},
+		{
+			name:        "unknown severity",
+			files:       map[string]string{"a.yaml": "rules:\n- name: r\n  severity: error\n  expression: 'true'\n"},
+			errContains: `rule r: severity "error" is not warn or deny`,
+		},
+		{
+			name:        "unknown language",
+			files:       map[string]string{"a.yaml": "rules:\n- name: r\n  language: rego\n  expression: 'true'\n"},
+			errContains: `rule r: language "rego" is not supported, use cel`,
+		},
+		{
+			name:        "invalid expression",
+			files:       map[string]string{"a.yaml": "rules:\n- name: r\n  expression: 'resource.'\n"},
+			errContains: "rule r:",
+		},
+		{
+			name:        "expression not returning a bool",
+			files:       map[string]string{"a.yaml": "rules:\n- name: r\n  expression: 'logicalId'\n"},
+			errContains: "rule r: expression returns string, not bool",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := Load(writeFiles(t, tt.files))
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Fatalf("expected error to contain %q, got %v", tt.errContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			var names []string
+			for _, r := range rules {
+				names = append(names, r.Name)
+				if r.Severity != SeverityDeny {
+					t.Errorf("expected rule %s to default to %s, got %s", r.Name, SeverityDeny, r.Severity)
+				}
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("expected rules %v, got %v", tt.want, names)
+			}
+		})
+	}
+}
+
+func TestLoadMissingDir(t *testing.T) {
+	rules, err := Load(filepath.Join(t.TempDir(), "policies"))
+	if err != nil || rules != nil {
+		t.Errorf("expected no rules for a missing directory, got %v, %v", rules, err)
+	}
+}
+
+const currentTemplate = `
+Resources:
+  Bucket:
+    Type: AWS::S3::Bucket
+    Properties:
+      BucketName: data
+  Queue:
+    Type: AWS::SQS::Queue
+`
+
+const newTemplate = `
+Resources:
+  Bucket:
+    Type: AWS::S3::Bucket
+    Properties:
+      BucketName: data
+      BucketEncryption:
+        ServerSideEncryptionConfiguration: []
+  Logs:
+    Type: AWS::S3::Bucket
+`
+
+func TestEvaluate(t *testing.T) {
+	changes := []types.Change{
+		{ResourceChange: &types.ResourceChange{
+			LogicalResourceId: aws.String("Bucket"),
+			ResourceType:      aws.String("AWS::S3::Bucket"),
+			Action:            types.ChangeActionModify,
+			Replacement:       types.ReplacementTrue,
+		}},
+		{ResourceChange: &types.ResourceChange{
+			LogicalResourceId: aws.String("Queue"),
+			ResourceType:      aws.String("AWS::SQS::Queue"),
+			Action:            types.ChangeActionRemove,
+		}},
+	}
+
+	tests := []struct {
+		name string
+		rule string
+		want []string
+	}{
+		{
+			name: "resource types",
+			rule: "name: encrypted\nresourceTypes: [AWS::S3::Bucket]\ndescription: Buckets must be encrypted\nexpression: has(resource.Properties.BucketEncryption)",
+			want: []string{"encrypted: AWS::S3::Bucket Logs: Buckets must be encrypted (no such key: Properties)"},
+		},
+		{
+			name: "removed resources are checked with the current template",
+			rule: "name: keep-queues\nseverity: warn\nmessage: Queues are kept\nexpression: '!has(change.Action) || change.Action != \"Remove\"'",
+			want: []string{"keep-queues: AWS::SQS::Queue Queue: Queues are kept"},
+		},
+		{
+			name: "change and current resource",
+			rule: "name: no-replacements\nexpression: 'current == {} || change == {} || change.Replacement != \"True\"'",
+			want: []string{"no-replacements: AWS::S3::Bucket Bucket: the resource does not comply with the rule"},
+		},
+		{
+			name: "templates",
+			rule: "name: small\nexpression: size(template.Resources) <= size(currentTemplate.Resources) && logicalId != 'Queue'",
+			want: []string{"small: AWS::SQS::Queue Queue: the resource does not comply with the rule"},
+		},
+		{
+			name: "evaluation errors are violations",
+			rule: "name: named\nmessage: Buckets are named\nexpression: resource.Properties.BucketName != ''",
+			want: []string{
+				"named: AWS::S3::Bucket Logs: Buckets are named (no such key: Properties)",
+				"named: AWS::SQS::Queue Queue: Buckets are named (no such key: Properties)",
+			},
+		},
+		{
+			name: "dynamic results must be bools",
+			rule: "name: dyn\nexpression: resource.Type",
+			want: []string{
+				"dyn: AWS::S3::Bucket Bucket: the resource does not comply with the rule (expression returned AWS::S3::Bucket, not a bool)",
+				"dyn: AWS::S3::Bucket Logs: the resource does not comply with the rule (expression returned AWS::S3::Bucket, not a bool)",
+				"dyn: AWS::SQS::Queue Queue: the resource does not comply with the rule (no such key: Type)",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := Load(writeFiles(t, map[string]string{"rules.yaml": "rules:\n- " + strings.ReplaceAll(tt.rule, "\n", "\n  ")}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			violations, err := Evaluate(rules, changes, []byte(currentTemplate), []byte(newTemplate))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, v := range violations {
+				got = append(got, v.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected violations\n%s\ngot\n%s", strings.Join(tt.want, "\n"), strings.Join(got, "\n"))
+			}
+		})
+	}
+}
+
+func TestEvaluateNoRules(t *testing.T) {
+	violations, err := Evaluate(nil, nil, []byte("not: [yaml"), nil)
+	if err != nil || violations != nil {
+		t.Errorf("expected no rules to skip the evaluation, got %v, %v", violations, err)
+	}
+}
+
+func TestDenied(t *testing.T) {
+	warn := Violation{Rule: "w", Severity: SeverityWarn, LogicalID: "A", ResourceType: "AWS::SQS::Queue", Message: "m"}
+	deny := Violation{Rule: "d", Severity: SeverityDeny, LogicalID: "B", ResourceType: "AWS::S3::Bucket", Message: "m"}
+
+	if err := Denied([]Violation{warn}); err != nil {
+		t.Errorf("expected warnings to pass, got %s", err)
+	}
+	err := Denied([]Violation{warn, deny, deny})
+	if want := "the stack violates 2 policy rule(s): d: AWS::S3::Bucket B: m; d: AWS::S3::Bucket B: m"; err == nil || err.Error() != want {
+		t.Errorf("expected %q, got %v", want, err)
+	}
+}
@@ -0,0 +1,308 @@
+package report
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/acorn-io/aws/utils/cdk-runner/pkg/policy"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+)
+
+const (
+	// Version is the version of the report schema, it changes when fields are removed or change meaning
+	Version = "v1"
+
+	StatusSucceeded = "Succeeded"
+	StatusFailed    = "Failed"
+)
+
+// Report is the machine-readable summary of a run, written as JSON once the run is done
+type Report struct {
+	Version         string             `json:"version"`
+	StackName       string             `json:"stackName"`
+	Event           string             `json:"event"`
+	DryRun          bool               `json:"dryRun"`
+	Status          string             `json:"status"`
+	Error           string             `json:"error,omitempty"`
+	StackStatus     string             `json:"stackStatus,omitempty"`
+	StartTime       time.Time          `json:"startTime"`
+	EndTime         time.Time          `json:"endTime"`
+	DurationSeconds float64            `json:"durationSeconds"`
+	ChangeSet       *ChangeSet         `json:"changeSet,omitempty"`
+	Resources       []Resource         `json:"resources"`
+	Events          []Event            `json:"events"`
+	Failures        []Event            `json:"failures"`
+	Violations      []policy.Violation `json:"violations"`
+	Drift           *Drift             `json:"drift,omitempty"`
+	Assets          []Asset            `json:"assets"`
+	Outputs         map[string]string  `json:"outputs"`
+
+	mu       sync.Mutex
+	eventIDs map[string]bool
+}
+
+// ChangeSet summarizes the change set of an update
+type ChangeSet struct {
+	ID string `json:"id"`
+	// Actions counts the resource changes by action: Add, Modify, Remove, Import and Dynamic
+	Actions map[string]int `json:"actions"`
+	Changes []Change       `json:"changes"`
+}
+
+// Change is the change of a single resource in a change set
+type Change struct {
+	LogicalID    string `json:"logicalId"`
+	ResourceType string `json:"resourceType"`
+	Action       string `json:"action"`
+	// Replacement is True, False or Conditional for modified resources
+	Replacement string `json:"replacement,omitempty"`
+}
+
+// Asset is a file or image asset of the cloud assembly, published before the change set
+type Asset struct {
+	ID string `json:"id"`
+	// Type is file or image
+	Type string `json:"type"`
+	// Destination is the S3 URL of a file asset or the reference of an image asset
+	Destination string `json:"destination"`
+	// Published is false when the asset was already there from an earlier run
+	Published bool `json:"published"`
+}
+
+// Drift is the result of the drift detection before an update
+type Drift struct {
+	// Policy is the drift policy of the stack: report, warn or block
+	Policy string `json:"policy"`
+	// Status is DRIFTED, IN_SYNC or UNKNOWN
+	Status    string          `json:"status"`
+	Resources []DriftResource `json:"resources"`
+}
+
+// DriftResource is a resource that was modified or deleted outside of CloudFormation
+type DriftResource struct {
+	LogicalID    string          `json:"logicalId"`
+	ResourceType string          `json:"resourceType"`
+	Status       string          `json:"status"`
+	Properties   []DriftProperty `json:"properties,omitempty"`
+}
+
+// DriftProperty is a property of a drifted resource that differs from the template
+type DriftProperty struct {
+	Path           string `json:"path"`
+	Expected       string `json:"expected,omitempty"`
+	Actual         string `json:"actual,omitempty"`
+	DifferenceType string `json:"differenceType"`
+}
+
+// Event is a stack event of the run
+type Event struct {
+	Timestamp    time.Time `json:"timestamp"`
+	LogicalID    string    `json:"logicalId"`
+	PhysicalID   string    `json:"physicalId,omitempty"`
+	ResourceType string    `json:"resourceType"`
+	Status       string    `json:"status"`
+	Reason       string    `json:"reason,omitempty"`
+}
+
+// Resource is the outcome of the run for a single resource, from its first to its last event
+type Resource struct {
+	LogicalID       string    `json:"logicalId"`
+	ResourceType    string    `json:"resourceType"`
+	Status          string    `json:"status"`
+	StartTime       time.Time `json:"startTime"`
+	EndTime         time.Time `json:"endTime"`
+	DurationSeconds float64   `json:"durationSeconds"`
+}
+
+// New starts the report of a run. Reports are optional, so the methods that record the run do nothing on a nil
+// report.
+func New(stackName, event string, dryRun bool) *Report {
+	return &Report{
+		Version:    Version,
+		StackName:  stackName,
+		Event:      event,
+		DryRun:     dryRun,
+		StartTime:  time.Now().UTC(),
+		Resources:  []Resource{},
+		Events:     []Event{},
+		Failures:   []Event{},
+		Violations: []policy.Violation{},
+		Assets:     []Asset{},
+		Outputs:    map[string]string{},
+		eventIDs:   map[string]bool{},
+	}
+}
+
+// SetChangeSet records the changes of the change set
+func (r *Report) SetChangeSet(id string, changes []types.Change) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cs := &ChangeSet{ID: id, Actions: map[string]int{}, Changes: []Change{}}
+	for _, change := range changes {
+		rc := change.ResourceChange
+		if rc == nil {
+			continue
+		}
+		cs.Actions[string(rc.Action)]++
+		cs.Changes = append(cs.Changes, Change{
+			LogicalID:    aws.ToString(rc.LogicalResourceId),
+			ResourceType: aws.ToString(rc.ResourceType),
+			Action:       string(rc.Action),
+			Replacement:  string(rc.Replacement),
+		})
+	}
+	r.ChangeSet = cs
+}
+
+// SetViolations records the policy violations of the change set
+func (r *Report) SetViolations(violations []policy.Violation) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.Violations = append([]policy.Violation{}, violations...)
+}
+
+// AddAsset records a published asset
+func (r *Report) AddAsset(asset Asset) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.Assets = append(r.Assets, asset)
+}
+
+// SetDrift records the result of the drift detection
+func (r *Report) SetDrift(drift *Drift) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.Drift = drift
+}
+
+// AddEvent records a stack event. Events from before the run and events that were already recorded are ignored.
+func (r *Report) AddEvent(event types.StackEvent) {
+	if r == nil || event.Timestamp == nil || event.Timestamp.Before(r.StartTime) {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := aws.ToString(event.EventId)
+	if r.eventIDs[id] {
+		return
+	}
+	r.eventIDs[id] = true
+
+	e := Event{
+		Timestamp:    event.Timestamp.UTC(),
+		LogicalID:    aws.ToString(event.LogicalResourceId),
+		PhysicalID:   aws.ToString(event.PhysicalResourceId),
+		ResourceType: aws.ToString(event.ResourceType),
+		Status:       string(event.ResourceStatus),
+		Reason:       aws.ToString(event.ResourceStatusReason),
+	}
+	r.Events = append(r.Events, e)
+	if strings.HasSuffix(e.Status, "_FAILED") {
+		r.Failures = append(r.Failures, e)
+	}
+}
+
+// SetStack records the status and outputs of the stack
+func (r *Report) SetStack(stack types.Stack) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.StackStatus = string(stack.StackStatus)
+	for _, output := range stack.Outputs {
+		r.Outputs[aws.ToString(output.OutputKey)] = aws.ToString(output.OutputValue)
+	}
+}
+
+// SetStackStatus records the status of a stack that cannot be described anymore, like a deleted one
+func (r *Report) SetStackStatus(status types.StackStatus) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.StackStatus = string(status)
+}
+
+// Finish completes the report with the result of the run
+func (r *Report) Finish(err error) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.EndTime = time.Now().UTC()
+	r.DurationSeconds = r.EndTime.Sub(r.StartTime).Seconds()
+	r.Status = StatusSucceeded
+	if err != nil {
+		r.Status = StatusFailed
+		r.Error = err.Error()
+	}
+
+	sort.SliceStable(r.Events, func(i, j int) bool {
+		return r.Events[i].Timestamp.Before(r.Events[j].Timestamp)
+	})
+	r.Resources = resources(r.Events)
+}
+
+// resources summarizes the sorted events by resource
+func resources(events []Event) []Resource {
+	byID := map[string]*Resource{}
+	for _, e := range events {
+		res, ok := byID[e.LogicalID]
+		if !ok {
+			res = &Resource{LogicalID: e.LogicalID, ResourceType: e.ResourceType, StartTime: e.Timestamp}
+			byID[e.LogicalID] = res
+		}
+		res.Status = e.Status
+		res.EndTime = e.Timestamp
+		res.DurationSeconds = res.EndTime.Sub(res.StartTime).Seconds()
+	}
+
+	result := make([]Resource, 0, len(byID))
+	for _, res := range byID {
+		result = append(result, *res)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].LogicalID < result[j].LogicalID
+	})
+	return result
+}
+
+// WriteFile writes the report as indented JSON
+func (r *Report) WriteFile(filename string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	data, err := json.MarshalIndent(r, "", "    ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, data, 0644)
+}
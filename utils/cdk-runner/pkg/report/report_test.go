@@ -0,0 +1,143 @@
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+)
+
+func event(id, logicalID, status string, at time.Time) types.StackEvent {
+	return types.StackEvent{
+		EventId:           aws.String(id),
+		LogicalResourceId: aws.String(logicalID),
+		ResourceType:      aws.String("AWS::SQS::Queue"),
+		ResourceStatus:    types.ResourceStatus(status),
+		Timestamp:         aws.Time(at),
+	}
+}
+
+func TestReport(t *testing.T) {
+	r := New("my-stack", "update", false)
+	start := r.StartTime
+
+	r.AddEvent(event("old", "Queue", "UPDATE_FAILED", start.Add(-time.Hour)))
+	r.AddEvent(event("2", "Queue", "UPDATE_COMPLETE", start.Add(3*time.Second)))
+	r.AddEvent(event("1", "Queue", "UPDATE_IN_PROGRESS", start.Add(time.Second)))
+	r.AddEvent(event("1", "Queue", "UPDATE_IN_PROGRESS", start.Add(time.Second)))
+	r.AddEvent(event("3", "Topic", "CREATE_FAILED", start.Add(2*time.Second)))
+
+	r.SetChangeSet("cs", []types.Change{
+		{ResourceChange: &types.ResourceChange{LogicalResourceId: aws.String("Queue"), Action: types.ChangeActionModify, Replacement: types.ReplacementFalse}},
+		{ResourceChange: &types.ResourceChange{LogicalResourceId: aws.String("Topic"), Action: types.ChangeActionAdd}},
+		{},
+	})
+	r.SetStack(types.Stack{
+		StackStatus: types.StackStatusUpdateRollbackComplete,
+		Outputs:     []types.Output{{OutputKey: aws.String("QueueURL"), OutputValue: aws.String("url")}},
+	})
+	r.AddAsset(Asset{ID: "abc", Type: "file", Destination: "s3://assets/abc.zip", Published: true})
+	r.Finish(errors.New("update failed"))
+
+	if r.Status != StatusFailed || r.Error != "update failed" {
+		t.Errorf("expected the failed status and error, got %s %q", r.Status, r.Error)
+	}
+
+	var statuses []string
+	for _, e := range r.Events {
+		statuses = append(statuses, e.Status)
+	}
+	if want := []string{"UPDATE_IN_PROGRESS", "CREATE_FAILED", "UPDATE_COMPLETE"}; !reflect.DeepEqual(statuses, want) {
+		t.Errorf("expected the events of the run once and in order, %v, got %v", want, statuses)
+	}
+	if len(r.Failures) != 1 || r.Failures[0].LogicalID != "Topic" {
+		t.Errorf("expected the Topic failure, got %+v", r.Failures)
+	}
+
+	if want := map[string]int{"Modify": 1, "Add": 1}; !reflect.DeepEqual(r.ChangeSet.Actions, want) {
+		t.Errorf("expected actions %v, got %v", want, r.ChangeSet.Actions)
+	}
+
+	queue := r.Resources[0]
+	if queue.LogicalID != "Queue" || queue.Status != "UPDATE_COMPLETE" || queue.DurationSeconds != 2 {
+		t.Errorf("expected Queue to complete in 2 seconds, got %+v", queue)
+	}
+
+	file := filepath.Join(t.TempDir(), "report.json")
+	if err := r.WriteFile(file); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := map[string]any{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatal(err)
+	}
+
+	// The fields are the schema the render script and CI read, changing them is a new version
+	var keys []string
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	want := []string{
+		"assets", "changeSet", "dryRun", "durationSeconds", "endTime", "error", "event", "events", "failures",
+		"outputs", "resources", "stackName", "stackStatus", "startTime", "status", "version", "violations",
+	}
+	if !reflect.DeepEqual(keys, want) {
+		t.Errorf("expected fields %v, got %v", want, keys)
+	}
+	if fields["version"] != Version || fields["stackStatus"] != "UPDATE_ROLLBACK_COMPLETE" {
+		t.Errorf("unexpected version or stack status in %s", data)
+	}
+	if outputs := fields["outputs"].(map[string]any); outputs["QueueURL"] != "url" {
+		t.Errorf("expected the QueueURL output, got %v", outputs)
+	}
+}
+
+func TestReportEmpty(t *testing.T) {
+	r := New("my-stack", "create", true)
+	r.Finish(nil)
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := map[string]any{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatal(err)
+	}
+
+	// Lists are empty rather than null, so readers can iterate them without checking
+	for _, k := range []string{"resources", "events", "failures", "violations", "assets"} {
+		if list, ok := fields[k].([]any); !ok || len(list) != 0 {
+			t.Errorf("expected %s to be an empty list, got %v", k, fields[k])
+		}
+	}
+	for _, k := range []string{"error", "changeSet", "drift", "stackStatus"} {
+		if _, ok := fields[k]; ok {
+			t.Errorf("expected %s to be left out, got %v", k, fields[k])
+		}
+	}
+	if fields["status"] != StatusSucceeded {
+		t.Errorf("expected %s, got %v", StatusSucceeded, fields["status"])
+	}
+}
+
+func TestReportNil(t *testing.T) {
+	var r *Report
+	r.AddEvent(event("1", "Queue", "CREATE_COMPLETE", time.Now()))
+	r.SetChangeSet("cs", nil)
+	r.SetStackStatus(types.StackStatusDeleteComplete)
+	r.AddAsset(Asset{})
+	r.Finish(nil)
+}
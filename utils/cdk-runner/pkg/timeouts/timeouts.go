@@ -0,0 +1,110 @@
+package timeouts
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Config holds the timeouts and polling intervals of a run
+type Config struct {
+	// Operation bounds a deployment or deletion, and waiting for the stack to finish an operation in progress
+	Operation time.Duration
+	// ChangeSet bounds the creation of a change set
+	ChangeSet time.Duration
+	// Hook bounds each hook
+	Hook time.Duration
+	// TransitionPoll is how often the stack is checked while another operation is in progress
+	TransitionPoll time.Duration
+	// EventPoll is how often the stack events are logged
+	EventPoll time.Duration
+	// WatcherPoll is how often the progress is reported to the Acorn
+	WatcherPoll time.Duration
+	// DriftPoll is how often the status of the drift detection is checked
+	DriftPoll time.Duration
+}
+
+// setting is a field of the config with its env var and flag
+type setting struct {
+	field *time.Duration
+	env   string
+	flag  string
+	usage string
+	// bounded settings may not be longer than the operation they are part of
+	bounded bool
+}
+
+func (s setting) name() string {
+	return fmt.Sprintf("%s (--%s)", s.env, s.flag)
+}
+
+func (c *Config) settings() []setting {
+	return []setting{
+		{&c.Operation, "CDK_RUNNER_TIMEOUT", "timeout", "time a deployment or deletion may take", false},
+		{&c.ChangeSet, "CDK_RUNNER_CHANGE_SET_TIMEOUT", "change-set-timeout", "time the creation of a change set may take", true},
+		{&c.Hook, "CDK_RUNNER_HOOK_TIMEOUT", "hook-timeout", "time each hook may take", false},
+		{&c.TransitionPoll, "CDK_RUNNER_TRANSITION_POLL_INTERVAL", "transition-poll-interval", "how often to check a stack with an operation in progress", true},
+		{&c.EventPoll, "CDK_RUNNER_EVENT_POLL_INTERVAL", "event-poll-interval", "how often to log the stack events", true},
+		{&c.WatcherPoll, "CDK_RUNNER_WATCHER_POLL_INTERVAL", "watcher-poll-interval", "how often to report the progress to the Acorn", true},
+		{&c.DriftPoll, "CDK_RUNNER_DRIFT_POLL_INTERVAL", "drift-poll-interval", "how often to check the status of the drift detection", true},
+	}
+}
+
+// Default returns the default timeouts and polling intervals
+func Default() Config {
+	return Config{
+		Operation:      60 * time.Minute,
+		ChangeSet:      5 * time.Minute,
+		Hook:           10 * time.Minute,
+		TransitionPoll: 30 * time.Second,
+		EventPoll:      5 * time.Second,
+		WatcherPoll:    30 * time.Second,
+		DriftPoll:      5 * time.Second,
+	}
+}
+
+// FromEnv returns the defaults, overridden by the env vars that are set. The env vars take durations like 90m.
+func FromEnv() (Config, error) {
+	c := Default()
+	var errs []error
+	for _, s := range c.settings() {
+		v := os.Getenv(s.env)
+		if v == "" {
+			continue
+		}
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.env, err))
+			continue
+		}
+		*s.field = d
+	}
+	if err := errors.Join(errs...); err != nil {
+		return c, err
+	}
+	return c, c.Validate()
+}
+
+// RegisterFlags adds a flag for each setting, defaulting to its current value
+func (c *Config) RegisterFlags(flags *flag.FlagSet) {
+	for _, s := range c.settings() {
+		flags.DurationVar(s.field, s.flag, *s.field, fmt.Sprintf("%s, also set by %s", s.usage, s.env))
+	}
+}
+
+// Validate checks that every setting is positive, and that nothing waits or polls longer than the whole operation
+func (c *Config) Validate() error {
+	var errs []error
+	settings := c.settings()
+	for _, s := range settings {
+		switch {
+		case *s.field <= 0:
+			errs = append(errs, fmt.Errorf("%s must be positive, got %s", s.name(), *s.field))
+		case s.bounded && c.Operation > 0 && *s.field > c.Operation:
+			errs = append(errs, fmt.Errorf("%s of %s is longer than %s of %s", s.name(), *s.field, settings[0].name(), c.Operation))
+		}
+	}
+	return errors.Join(errs...)
+}
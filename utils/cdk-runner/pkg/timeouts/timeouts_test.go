@@ -0,0 +1,142 @@
+package timeouts
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFromEnv(t *testing.T) {
+	tests := []struct {
+		name        string
+		env         map[string]string
+		want        func(c *Config)
+		errContains string
+	}{
+		{
+			name: "defaults",
+			want: func(c *Config) {},
+		},
+		{
+			name: "overrides",
+			env: map[string]string{
+				"CDK_RUNNER_TIMEOUT":             "90m",
+				"CDK_RUNNER_EVENT_POLL_INTERVAL": "10s",
+			},
+			want: func(c *Config) {
+				c.Operation = 90 * time.Minute
+				c.EventPoll = 10 * time.Second
+			},
+		},
+		{
+			name: "hook timeout",
+			env:  map[string]string{"CDK_RUNNER_HOOK_TIMEOUT": "30m"},
+			want: func(c *Config) {
+				c.Hook = 30 * time.Minute
+			},
+		},
+		{
+			name:        "invalid duration",
+			env:         map[string]string{"CDK_RUNNER_TIMEOUT": "an hour"},
+			errContains: "CDK_RUNNER_TIMEOUT",
+		},
+		{
+			name:        "invalid after parsing",
+			env:         map[string]string{"CDK_RUNNER_CHANGE_SET_TIMEOUT": "2h"},
+			errContains: "CDK_RUNNER_CHANGE_SET_TIMEOUT (--change-set-timeout) of 2h0m0s is longer than CDK_RUNNER_TIMEOUT (--timeout) of 1h0m0s",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, s := range (&Config{}).settings() {
+				t.Setenv(s.env, "")
+			}
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			c, err := FromEnv()
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Fatalf("expected error to contain %q, got %v", tt.errContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			want := Default()
+			tt.want(&want)
+			if c != want {
+				t.Errorf("expected %+v, got %+v", want, c)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      func(c *Config)
+		errContains []string
+	}{
+		{
+			name:   "defaults",
+			config: func(c *Config) {},
+		},
+		{
+			name: "zero",
+			config: func(c *Config) {
+				c.Hook = 0
+			},
+			errContains: []string{"CDK_RUNNER_HOOK_TIMEOUT (--hook-timeout) must be positive, got 0s"},
+		},
+		{
+			name: "negative",
+			config: func(c *Config) {
+				c.EventPoll = -time.Second
+			},
+			errContains: []string{"CDK_RUNNER_EVENT_POLL_INTERVAL (--event-poll-interval) must be positive"},
+		},
+		{
+			name: "poll longer than the operation",
+			config: func(c *Config) {
+				c.Operation = time.Minute
+			},
+			errContains: []string{
+				"CDK_RUNNER_CHANGE_SET_TIMEOUT (--change-set-timeout) of 5m0s is longer than",
+				"CDK_RUNNER_TIMEOUT (--timeout) of 1m0s",
+			},
+		},
+		{
+			name: "hooks may take longer than the operation",
+			config: func(c *Config) {
+				c.Hook = 2 * time.Hour
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Default()
+			tt.config(&c)
+			err := c.Validate()
+			if len(tt.errContains) == 0 {
+				if err != nil {
+					t.Errorf("unexpected error: %s", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected an error")
+			}
+			for _, s := range tt.errContains {
+				if !strings.Contains(err.Error(), s) {
+					t.Errorf("expected error to contain %q, got %q", s, err)
+				}
+			}
+		})
+	}
+}